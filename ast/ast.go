@@ -142,6 +142,10 @@ func (es ExpressionStatement) String() string {
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+
+	// the object this literal evaluates to, built by the parser so
+	// evaluating the node doesn't allocate (nil for hand-built trees)
+	Cached any
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
@@ -151,6 +155,10 @@ func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 type FloatLiteral struct {
 	Token token.Token
 	Value float32
+
+	// the object this literal evaluates to, built by the parser so
+	// evaluating the node doesn't allocate (nil for hand-built trees)
+	Cached any
 }
 
 func (fl *FloatLiteral) expressionNode()      {}
@@ -169,6 +177,10 @@ func (cl *CommentLiteral) String() string       { return cl.Token.Literal }
 type StringLiteral struct {
 	Token token.Token
 	Value string
+
+	// the object this literal evaluates to, built by the parser so
+	// evaluating the node doesn't allocate (nil for hand-built trees)
+	Cached any
 }
 
 func (sl *StringLiteral) expressionNode()      {}
@@ -299,10 +311,48 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// a[start:end:step], bounds that were left out are nil
+type SliceExpression struct {
+	Token token.Token
+	Left  Expression
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	bound := func(exp Expression) string {
+		if exp == nil {
+			return ""
+		}
+		return exp.String()
+	}
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	out.WriteString(bound(se.Start))
+	out.WriteString(":")
+	out.WriteString(bound(se.End))
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 // define hash for ast
+// Keys holds the keys of Pairs in the order they appear in the source
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	Keys  []Expression
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -311,8 +361,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -0,0 +1,308 @@
+package evaluate
+
+import (
+	"sort"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+array builtins. Like push and rest they never change the array they are
+given, they return a new one. Indices can be negative and count from the
+end. contains and index_of search arrays here and hand strings to
+stringContains and stringIndexOf in strings.go. range and fill can build arrays far larger than their
+arguments, they check the interpreter's allocation budget first and are
+bound to it like the other context builtins.
+*/
+var arrayBuiltins map[string]*object.Builtin
+
+// filled in init because sort calls back into user functions
+func init() {
+	arrayBuiltins = map[string]*object.Builtin{
+		/*
+			sort(arr) orders the elements with <, sort(arr, cmp) asks cmp(a, b)
+			instead: true or a negative integer puts a before b. The sort is
+			stable and takes O(n log n) comparisons.
+		*/
+		"sort": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("sort", args, 1, object.ARRAY_OBJ, anyArg); err != nil {
+					return err
+				}
+				elements := append([]object.Object{}, args[0].(*object.Array).Elements...)
+
+				var sortErr object.Object
+				less := func(a, b object.Object) bool {
+					result, ok := compareObjects(a, b)
+					if !ok {
+						sortErr = newError("sort: can't order %s and %s", a.Type(), b.Type())
+					}
+					return result < 0
+				}
+				if len(args) == 2 {
+					cmp := args[1]
+					less = func(a, b object.Object) bool {
+						before, err := comparatorResult(callFunction(cmp, a, b))
+						if err != nil {
+							sortErr = err
+						}
+						return before
+					}
+				}
+				sort.SliceStable(elements, func(i, j int) bool {
+					return sortErr == nil && less(elements[i], elements[j])
+				})
+				if sortErr != nil {
+					return sortErr
+				}
+				return &object.Array{Elements: elements}
+			},
+		},
+		"reverse": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("reverse", args, 1, object.ARRAY_OBJ); err != nil {
+					return err
+				}
+				elements := args[0].(*object.Array).Elements
+				reversed := make([]object.Object, len(elements))
+				for i, el := range elements {
+					reversed[len(elements)-1-i] = el
+				}
+				return &object.Array{Elements: reversed}
+			},
+		},
+		// concat(a, b, ...) joins any number of arrays
+		"concat": {
+			Fn: func(args ...object.Object) object.Object {
+				elements := []object.Object{}
+				for i, arg := range args {
+					arr, ok := arg.(*object.Array)
+					if !ok {
+						return newError("argument %d to `concat` must be ARRAY, got %s", i+1, arg.Type())
+					}
+					elements = append(elements, arr.Elements...)
+				}
+				return &object.Array{Elements: elements}
+			},
+		},
+		// insert(arr, i, x) puts x before position i, i == len(arr) appends and
+		// insert(arr, -1, x) puts x before the last element
+		"insert": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("insert", args, 3, object.ARRAY_OBJ, object.INTEGER_OBJ, anyArg); err != nil {
+					return err
+				}
+				elements := args[0].(*object.Array).Elements
+				idx := args[1].(*object.Integer).Value
+				if idx < 0 {
+					idx += int64(len(elements))
+				}
+				if idx < 0 || idx > int64(len(elements)) {
+					return newError("insert: index %d out of range for length %d", args[1].(*object.Integer).Value, len(elements))
+				}
+				inserted := make([]object.Object, 0, len(elements)+1)
+				inserted = append(inserted, elements[:idx]...)
+				inserted = append(inserted, args[2])
+				inserted = append(inserted, elements[idx:]...)
+				return &object.Array{Elements: inserted}
+			},
+		},
+		"remove_at": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("remove_at", args, 2, object.ARRAY_OBJ, object.INTEGER_OBJ); err != nil {
+					return err
+				}
+				elements := args[0].(*object.Array).Elements
+				idx, ok := normalizeIndex(args[1].(*object.Integer).Value, len(elements))
+				if !ok {
+					return newError("remove_at: index %d out of range for length %d", args[1].(*object.Integer).Value, len(elements))
+				}
+				removed := make([]object.Object, 0, len(elements)-1)
+				removed = append(removed, elements[:idx]...)
+				removed = append(removed, elements[idx+1:]...)
+				return &object.Array{Elements: removed}
+			},
+		},
+		// unique(arr) drops elements equal to an earlier one
+		"unique": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("unique", args, 1, object.ARRAY_OBJ); err != nil {
+					return err
+				}
+				seen := object.NewSet()
+				kept := []object.Object{}
+				for _, el := range args[0].(*object.Array).Elements {
+					if key, ok := object.AsHashable(el); ok {
+						if seen.Has(key) {
+							continue
+						}
+						seen.Add(key)
+					} else if arrayIndex(&object.Array{Elements: kept}, el) >= 0 {
+						continue
+					}
+					kept = append(kept, el)
+				}
+				return &object.Array{Elements: kept}
+			},
+		},
+		// chunk(arr, size) splits arr into arrays of size elements, the last one may be shorter
+		"chunk": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("chunk", args, 2, object.ARRAY_OBJ, object.INTEGER_OBJ); err != nil {
+					return err
+				}
+				elements := args[0].(*object.Array).Elements
+				size := int(args[1].(*object.Integer).Value)
+				if size <= 0 {
+					return newError("chunk size must be positive, got %d", size)
+				}
+				chunks := []object.Object{}
+				for start := 0; start < len(elements); start += size {
+					end := min(start+size, len(elements))
+					chunks = append(chunks, &object.Array{Elements: append([]object.Object{}, elements[start:end]...)})
+				}
+				return &object.Array{Elements: chunks}
+			},
+		},
+		// flatten(arr) removes one level of nesting, flatten(arr, depth) that many
+		"flatten": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("flatten", args, 1, object.ARRAY_OBJ, object.INTEGER_OBJ); err != nil {
+					return err
+				}
+				depth := int64(1)
+				if len(args) == 2 {
+					depth = args[1].(*object.Integer).Value
+				}
+				return &object.Array{Elements: flatten(args[0].(*object.Array).Elements, depth)}
+			},
+		},
+		// contains(arr, x) is true when an element equals x
+		"contains": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) == 2 && args[0].Type() == object.ARRAY_OBJ {
+					return nativeBoolToBooleanObject(arrayIndex(args[0], args[1]) >= 0)
+				}
+				return stringContains(args...)
+			},
+		},
+		// index_of(arr, x) is the position of the first element equal to x
+		"index_of": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) == 2 && args[0].Type() == object.ARRAY_OBJ {
+					return newInteger(int64(arrayIndex(args[0], args[1])))
+				}
+				return stringIndexOf(args...)
+			},
+		},
+	}
+	registerBuiltins(arrayBuiltins)
+}
+
+// range(end), range(start, end) or range(start, end, step), end is not included
+func builtinRange(it *Interpreter, args ...object.Object) object.Object {
+	err := checkArgs("range", args, 1, object.INTEGER_OBJ, object.INTEGER_OBJ, object.INTEGER_OBJ)
+	if err != nil {
+		return err
+	}
+	start, end, step := int64(0), args[0].(*object.Integer).Value, int64(1)
+	if len(args) > 1 {
+		start, end = end, args[1].(*object.Integer).Value
+	}
+	if len(args) == 3 {
+		step = args[2].(*object.Integer).Value
+	}
+	if step == 0 {
+		return newError("range step cannot be zero")
+	}
+	n := rangeLength(start, end, step)
+	if n > maxResultBytes/rangeElementBytes {
+		return newError("range: %d elements is too many", n)
+	}
+	if err := it.reserve(32 + int64(n)*rangeElementBytes); err != nil {
+		return err
+	}
+	elements := make([]object.Object, n)
+	for i := range elements {
+		if err := it.interrupted(i); err != nil {
+			return err
+		}
+		elements[i] = newInteger(start + int64(i)*step)
+	}
+	return &object.Array{Elements: elements}
+}
+
+// fill(n, x) is an array of n times x
+func builtinFill(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("fill", args, 2, object.INTEGER_OBJ, anyArg); err != nil {
+		return err
+	}
+	n := args[0].(*object.Integer).Value
+	if n < 0 {
+		return newError("fill count must not be negative, got %d", n)
+	}
+	if n > maxResultBytes/arraySlotBytes {
+		return newError("fill: %d elements is too many", n)
+	}
+	if err := it.reserve(32 + n*arraySlotBytes); err != nil {
+		return err
+	}
+	elements := make([]object.Object, n)
+	for i := range elements {
+		elements[i] = args[1]
+	}
+	return &object.Array{Elements: elements}
+}
+
+// estimated bytes per element, an array slot and, for range, an integer
+const (
+	arraySlotBytes    = 16
+	rangeElementBytes = 2 * arraySlotBytes
+)
+
+// number of values range(start, end, step) produces, worked out in uint64
+// so that end - start can't overflow
+func rangeLength(start, end, step int64) uint64 {
+	if step > 0 && start < end {
+		return (uint64(end-start)-1)/uint64(step) + 1
+	}
+	if step < 0 && start > end {
+		return (uint64(start-end)-1)/(-uint64(step)) + 1
+	}
+	return 0
+}
+
+// position of the first element equal to x, -1 when there is none
+func arrayIndex(arr, x object.Object) int {
+	for i, el := range arr.(*object.Array).Elements {
+		if objectsEqual(el, x) {
+			return i
+		}
+	}
+	return -1
+}
+
+// what a sort comparator returned: true or a negative integer means before
+func comparatorResult(result object.Object) (bool, object.Object) {
+	switch result := result.(type) {
+	case *object.Error:
+		return false, result
+	case *object.Boolean:
+		return result.Value, nil
+	case *object.Integer:
+		return result.Value < 0, nil
+	}
+	return false, newError("sort comparator must return BOOLEAN or INTEGER, got %s", result.Type())
+}
+
+func flatten(elements []object.Object, depth int64) []object.Object {
+	flat := []object.Object{}
+	for _, el := range elements {
+		if arr, ok := el.(*object.Array); ok && depth > 0 {
+			flat = append(flat, flatten(arr.Elements, depth-1)...)
+			continue
+		}
+		flat = append(flat, el)
+	}
+	return flat
+}
@@ -0,0 +1,70 @@
+package evaluate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`let a = push([1], 2); let b = push(a, 3); let c = push(a, 4); [a, b, c]`, []any{[]any{1, 2}, []any{1, 2, 3}, []any{1, 2, 4}}},
+		{`let a = push(push([], 1), 2); let b = rest(a); [push(b, 3), a]`, []any{[]any{2, 3}, []any{1, 2}}},
+		{`sort([3, 1, 2])`, []any{1, 2, 3}},
+		{`sort(["b", "c", "a"])`, []any{"a", "b", "c"}},
+		{`sort([3, 1, 2], df(a, b) { a > b })`, []any{3, 2, 1}},
+		{`sort([[2, "b"], [1, "a"], [2, "a"]], df(a, b) { a[0] - b[0] })`, []any{[]any{1, "a"}, []any{2, "b"}, []any{2, "a"}}},
+		{`let a = [2, 1]; sort(a); a`, []any{2, 1}},
+		{`reverse([1, 2, 3])`, []any{3, 2, 1}},
+		{`reverse([])`, []any{}},
+		{`concat([1], [2, 3], [])`, []any{1, 2, 3}},
+		{`insert([1, 3], 1, 2)`, []any{1, 2, 3}},
+		{`insert([1, 2], 2, 3)`, []any{1, 2, 3}},
+		{`insert([1, 3], -1, 2)`, []any{1, 2, 3}},
+		{`remove_at([1, 2, 3], 0)`, []any{2, 3}},
+		{`remove_at([1, 2, 3], -1)`, []any{1, 2}},
+		{`index_of([1, 2, 3], 2)`, 1},
+		{`index_of([[1], [2]], [2])`, 1},
+		{`index_of([1, 2, 3], 4)`, -1},
+		{`contains([1, 2.0, "a"], 2)`, true},
+		{`contains([1, 2], "a")`, false},
+		{`unique([1, 2, 1, 3, 2])`, []any{1, 2, 3}},
+		{`unique([df(x) { x }, [1], [1]])`, []any{inspected("df(x) {\nx\n}"), []any{1}}},
+		{`range(3)`, []any{0, 1, 2}},
+		{`range(2, 5)`, []any{2, 3, 4}},
+		{`range(5, 0, -2)`, []any{5, 3, 1}},
+		{`range(3, 1)`, []any{}},
+		{`range(0, 10, 4)`, []any{0, 4, 8}},
+		{`range(-9223372036854775807 - 1, -9223372036854775807 + 1)`, []any{int64(math.MinInt64), int64(math.MinInt64 + 1)}},
+		{`range(9223372036854775806, 9223372036854775807)`, []any{int64(math.MaxInt64 - 1)}},
+		{`range(1, -1, -9223372036854775807 - 1)`, []any{1}},
+		{`fill(3, 0)`, []any{0, 0, 0}},
+		{`fill(2, "a")`, []any{"a", "a"}},
+		{`chunk([1, 2, 3, 4, 5], 2)`, []any{[]any{1, 2}, []any{3, 4}, []any{5}}},
+		{`flatten([1, [2, [3]], []])`, []any{1, 2, []any{3}}},
+		{`flatten([1, [2, [3]]], 2)`, []any{1, 2, 3}},
+
+		{`sort([1, "a"])`, errorMessage("sort: can't order STRING and INTEGER")},
+		{`sort([1, NAN])`, errorMessage("sort: can't order FLOAT and INTEGER")},
+		{`sort([9007199254740993, 9007199254740992.0])`, inspected("[9007199254740992.000000, 9007199254740993]")},
+		{`sort([1, 2], df(a, b) { "x" })`, errorMessage("sort comparator must return BOOLEAN or INTEGER, got STRING")},
+		{`concat([1], 2)`, errorMessage("argument 2 to `concat` must be ARRAY, got INTEGER")},
+		{`insert([1], 3, 2)`, errorMessage("insert: index 3 out of range for length 1")},
+		{`remove_at([], 0)`, errorMessage("remove_at: index 0 out of range for length 0")},
+		{`range(0, 3, 0)`, errorMessage("range step cannot be zero")},
+		{`fill(-1, 0)`, errorMessage("fill count must not be negative, got -1")},
+		{`fill(4611686018427387904, 0)`, errorMessage("fill: 4611686018427387904 elements is too many")},
+		{`range(0, 4611686018427387904)`, errorMessage("range: 4611686018427387904 elements is too many")},
+		{`range(-9223372036854775807 - 1, 9223372036854775807)`, errorMessage("range: 18446744073709551615 elements is too many")},
+		{`chunk([1], 0)`, errorMessage("chunk size must be positive, got 0")},
+		{`reverse("abc")`, errorMessage("argument 1 to `reverse` must be ARRAY, got STRING")},
+	}
+
+	for _, tt := range tests {
+		if !testObject(t, testEval(tt.input), tt.expected) {
+			t.Errorf("for input %s", tt.input)
+		}
+	}
+}
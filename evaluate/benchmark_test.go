@@ -0,0 +1,73 @@
+package evaluate
+
+import (
+	"testing"
+
+	"github.com/JWSch4fer/interpreter/ast"
+	"github.com/JWSch4fer/interpreter/lexer"
+	"github.com/JWSch4fer/interpreter/object"
+	"github.com/JWSch4fer/interpreter/parser"
+)
+
+// parse once so the benchmarks only measure evaluation
+func benchmarkProgram(b *testing.B, input string) *ast.Program {
+	b.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		b.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+func runBenchmark(b *testing.B, input string) {
+	program := benchmarkProgram(b, input)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result := Eval(program, object.NewEnvironment())
+		if isError(result) {
+			b.Fatalf("evaluation failed: %s", result.Inspect())
+		}
+	}
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	runBenchmark(b, `
+let fib = df(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+fib(15);
+`)
+}
+
+func BenchmarkCounter(b *testing.B) {
+	runBenchmark(b, `
+let counter = df(x) { if (x > 500) { return x; } else { counter(x + 1); } };
+counter(0);
+`)
+}
+
+func BenchmarkStringBuilder(b *testing.B) {
+	runBenchmark(b, `
+let build = df(s, n) { if (n == 0) { s } else { build(s + "ab", n - 1) } };
+build("", 200);
+`)
+}
+
+func BenchmarkReduce(b *testing.B) {
+	runBenchmark(b, `
+let reduce = df(arr, initial, f) {
+    let iter = df(arr, result) {
+        if (len(arr) == 0) { result } else { iter(rest(arr), f(result, first(arr))); }
+    };
+    iter(arr, initial);
+};
+reduce([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], 0, df(acc, el) { acc + el });
+`)
+}
+
+func BenchmarkPush(b *testing.B) {
+	runBenchmark(b, `
+let fill = df(arr, n) { if (n == 0) { arr } else { fill(push(arr, n), n - 1) } };
+fill([], 500);
+`)
+}
@@ -2,9 +2,12 @@ package evaluate
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/JWSch4fer/interpreter/object"
 )
@@ -18,11 +21,13 @@ var builtins = map[string]*object.Builtin{
 			}
 			switch arg := args[0].(type) {
 			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
+				return newInteger(int64(len(arg.Elements)))
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return newInteger(int64(utf8.RuneCountInString(arg.Value)))
 			case *object.Hash:
-				return &object.Integer{Value: int64(len(arg.Pairs))}
+				return newInteger(int64(arg.Len()))
+			case *object.Set:
+				return newInteger(int64(arg.Len()))
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
@@ -81,6 +86,8 @@ var builtins = map[string]*object.Builtin{
 			return NULL
 		},
 	},
+	// push(arr, x) is a new array, arr is unchanged. Pushing in a loop is
+	// amortised O(1), see object.Array.Append
 	"push": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
@@ -91,24 +98,203 @@ var builtins = map[string]*object.Builtin{
 				return newError("argument to `push` must be ARRAY, got %s",
 					args[0].Type())
 			}
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			newElements := make([]object.Object, length+1, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
-			return &object.Array{Elements: newElements}
+			return args[0].(*object.Array).Append(args[1])
+		},
+	},
+	// set() is empty, set(collection) holds the elements of an array or set
+	// or the keys of a hash
+	"set": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+			set := object.NewSet()
+			if len(args) == 0 {
+				return set
+			}
+			elements, ok := elementsOf(args[0])
+			if !ok {
+				return newError("argument to `set` must be ARRAY, SET or HASH, got %s", args[0].Type())
+			}
+			for _, el := range elements {
+				key, ok := object.AsHashable(el)
+				if !ok {
+					return newError("unusable as set element: %s", el.Type())
+				}
+				set.Add(key)
+			}
+			return set
+		},
+	},
+	// freeze(x) makes x and every hash inside it read-only and returns x,
+	// a frozen hash can be used as a hash key
+	"freeze": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("freeze", args, 1, anyArg); err != nil {
+				return err
+			}
+			object.Freeze(args[0])
+			return args[0]
+		},
+	},
+	// is_frozen(x) tells whether x can still change: arrays never do
+	// themselves, so they are frozen when every hash inside them is
+	"is_frozen": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("is_frozen", args, 1, anyArg); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(object.IsFrozen(args[0]))
 		},
 	},
-	"print": {
+	// zip(a, b, ...) pairs up elements, stopping at the shortest array
+	"zip": {
 		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments. got=0, want at least 1")
+			}
+			shortest := -1
 			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("arguments to `zip` must be ARRAY, got %s", arg.Type())
+				}
+				if shortest < 0 || len(arr.Elements) < shortest {
+					shortest = len(arr.Elements)
+				}
 			}
-			return NULL
+			tuples := make([]object.Object, shortest)
+			for i := range tuples {
+				tuple := make([]object.Object, len(args))
+				for j, arg := range args {
+					tuple[j] = arg.(*object.Array).Elements[i]
+				}
+				tuples[i] = &object.Array{Elements: tuple}
+			}
+			return &object.Array{Elements: tuples}
+		},
+	},
+	// enumerate(arr) returns [index, element] pairs
+	"enumerate": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			elements, ok := elementsOf(args[0])
+			if !ok || args[0].Type() == object.HASH_OBJ {
+				return newError("argument to `enumerate` must be ARRAY or SET, got %s", args[0].Type())
+			}
+			pairs := make([]object.Object, len(elements))
+			for i, el := range elements {
+				pairs[i] = &object.Array{Elements: []object.Object{newInteger(int64(i)), el}}
+			}
+			return &object.Array{Elements: pairs}
+		},
+	},
+	"union": {
+		Fn: func(args ...object.Object) object.Object {
+			return setOperation("union", args, func(inLeft, inRight bool) bool { return inLeft || inRight })
+		},
+	},
+	"intersection": {
+		Fn: func(args ...object.Object) object.Object {
+			return setOperation("intersection", args, func(inLeft, inRight bool) bool { return inLeft && inRight })
+		},
+	},
+	"difference": {
+		Fn: func(args ...object.Object) object.Object {
+			return setOperation("difference", args, func(inLeft, inRight bool) bool { return inLeft && !inRight })
 		},
 	},
 }
 
+// the builtins of a module (strings.go, ...) are added to builtins in the
+// module's init, two builtins with the same name are a programming error
+func registerBuiltins(module map[string]*object.Builtin) {
+	for name, builtin := range module {
+		if _, ok := builtins[name]; ok {
+			panic("builtin registered twice: " + name)
+		}
+		builtins[name] = builtin
+	}
+}
+
+// accepts every type in checkArgs
+const anyArg object.ObjectType = "ANY"
+
+// no builtin builds a result of more than about this many bytes, limits
+// or not, so repeat("x", 1 << 62) is an error instead of a crash
+const maxResultBytes = 1 << 30
+
+/*
+checkArgs validates the arguments of a builtin: there have to be at least
+min and at most len(types) of them, and each has to have the type at its
+position in types.
+*/
+func checkArgs(name string, args []object.Object, min int, types ...object.ObjectType) *object.Error {
+	if len(args) < min || len(args) > len(types) {
+		if min == len(types) {
+			return newError("wrong number of arguments. got=%d, want=%d", len(args), min)
+		}
+		return newError("wrong number of arguments. got=%d, want=%d to %d", len(args), min, len(types))
+	}
+	for i, arg := range args {
+		if types[i] != anyArg && arg.Type() != types[i] {
+			return newError("argument %d to `%s` must be %s, got %s", i+1, name, types[i], arg.Type())
+		}
+	}
+	return nil
+}
+
+// elements to iterate over: array elements, set elements or hash keys
+func elementsOf(obj object.Object) ([]object.Object, bool) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Elements, true
+	case *object.Set:
+		return obj.Elements(), true
+	case *object.Hash:
+		pairs := obj.Pairs()
+		keys := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			keys[i] = pair.Key
+		}
+		return keys, true
+	}
+	return nil, false
+}
+
+// build a new set from the elements of two sets that satisfy keep,
+// left elements come first
+func setOperation(name string, args []object.Object, keep func(inLeft, inRight bool) bool) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	left, ok := args[0].(*object.Set)
+	if !ok {
+		return newError("arguments to `%s` must be SET, got %s", name, args[0].Type())
+	}
+	right, ok := args[1].(*object.Set)
+	if !ok {
+		return newError("arguments to `%s` must be SET, got %s", name, args[1].Type())
+	}
+
+	result := object.NewSet()
+	for _, el := range left.Elements() {
+		key := el.(object.Hashable)
+		if keep(true, right.Has(key)) {
+			result.Add(key)
+		}
+	}
+	for _, el := range right.Elements() {
+		key := el.(object.Hashable)
+		if !left.Has(key) && keep(false, true) {
+			result.Add(key)
+		}
+	}
+	return result
+}
+
 // Declare the new builtins map.
 // This allows us to avoid initialization cycles for certain types of functions
 var builtinWithGetter map[string]*object.Builtin
@@ -121,137 +307,397 @@ func init() {
 				if len(args) != 2 {
 					return newError("wrong number of arguments. got=%d, expected=2", len(args))
 				}
-
-				// First argument must be a function.
-				fn, ok := args[0].(*object.Function)
-				if !ok {
-					return newError("first argument to map must be a function, got %s", args[0].Type())
+				fn, elements, err := functionAndElements("map", args[0], args[1])
+				if err != nil {
+					return err
 				}
 
-				// Second argument must be an array.
-				arr, ok := args[1].(*object.Array)
-				if !ok {
-					return newError("second argument to map must be an array, got %s", args[1].Type())
+				results := make([]object.Object, len(elements))
+				for i, el := range elements {
+					mapped := callFunction(fn, el)
+					if isError(mapped) {
+						return mapped
+					}
+					results[i] = mapped
 				}
-
-				// If the array is empty, return an empty array.
-				if len(arr.Elements) == 0 {
-					return &object.Array{Elements: []object.Object{}}
+				return &object.Array{Elements: results}
+			},
+		},
+		"filter": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, expected=2", len(args))
 				}
-
-				// Determine the expected element type.
-				// For numeric arrays, we allow both INTEGER and FLOAT.
-				var expectedType string
-				firstType := arr.Elements[0].Type()
-				if firstType == object.STRING_OBJ {
-					expectedType = object.STRING_OBJ
-				} else if firstType == object.INTEGER_OBJ || firstType == object.FLOAT_OBJ {
-					expectedType = "NUMERIC"
-				} else {
-					return newError("map: unsupported element type %s", firstType)
+				fn, elements, err := functionAndElements("filter", args[0], args[1])
+				if err != nil {
+					return err
 				}
 
-				// Check that every element is homogeneous.
-				for _, el := range arr.Elements {
-					if expectedType == object.STRING_OBJ && el.Type() != object.STRING_OBJ {
-						return newError("map: array contains mixed types; expected all %s, got %s", expectedType, el.Type())
-					} else if expectedType == "NUMERIC" && (el.Type() != object.INTEGER_OBJ && el.Type() != object.FLOAT_OBJ) {
-						return newError("map: array contains mixed types; expected numeric types, got %s", el.Type())
+				results := []object.Object{}
+				for _, el := range elements {
+					keep := callFunction(fn, el)
+					if isError(keep) {
+						return keep
+					}
+					if isTruthy(keep) {
+						results = append(results, el)
+					}
+				}
+				return &object.Array{Elements: results}
+			},
+		},
+		// reduce(fn, collection, initial) folds from the left, without an
+		// initial value the first element is used
+		"reduce": {Fn: reduce},
+		"fold":   {Fn: reduce},
+		"any": {
+			Fn: func(args ...object.Object) object.Object {
+				found, err := findElement("any", args)
+				if err != nil {
+					return err
+				}
+				return nativeBoolToBooleanObject(found != nil)
+			},
+		},
+		"all": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, expected=2", len(args))
+				}
+				fn, elements, err := functionAndElements("all", args[0], args[1])
+				if err != nil {
+					return err
+				}
+				for _, el := range elements {
+					result := callFunction(fn, el)
+					if isError(result) {
+						return result
+					}
+					if !isTruthy(result) {
+						return FALSE
 					}
 				}
+				return TRUE
+			},
+		},
+		// first element fn is true for, NULL when there is none
+		"find": {
+			Fn: func(args ...object.Object) object.Object {
+				found, err := findElement("find", args)
+				if err != nil {
+					return err
+				}
+				if found == nil {
+					return NULL
+				}
+				return found
+			},
+		},
+		"flat_map": {
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, expected=2", len(args))
+				}
+				fn, elements, err := functionAndElements("flat_map", args[0], args[1])
+				if err != nil {
+					return err
+				}
 
-				// Apply the function to every element.
-				var results []object.Object
-				for _, el := range arr.Elements {
-					// Use a helper that defers the function call.
-					mapped := callUserFunction(fn, []object.Object{el})
-					if mapped.Type() == object.ERROR_OBJ {
+				results := []object.Object{}
+				for _, el := range elements {
+					mapped := callFunction(fn, el)
+					if isError(mapped) {
 						return mapped
 					}
-					results = append(results, mapped)
+					arr, ok := mapped.(*object.Array)
+					if !ok {
+						return newError("function passed to flat_map must return ARRAY, got %s", mapped.Type())
+					}
+					results = append(results, arr.Elements...)
 				}
 				return &object.Array{Elements: results}
 			},
 		},
-		"read_file": {
+		// stable sort by the key fn returns for each element
+		"sort_by": {
 			Fn: func(args ...object.Object) object.Object {
-				if len(args) < 2 || len(args) > 3 {
-					return newError("wrong number of arguments: got %d, expected 2 or 3", len(args))
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, expected=2", len(args))
+				}
+				fn, elements, err := functionAndElements("sort_by", args[0], args[1])
+				if err != nil {
+					return err
 				}
 
-				//First argument is a file path
-				filePathObj, ok := args[0].(*object.String)
-				if !ok {
-					return newError("first argument must be a string (file path) got %s", args[0])
+				keys := make([]object.Object, len(elements))
+				for i, el := range elements {
+					keys[i] = callFunction(fn, el)
+					if isError(keys[i]) {
+						return keys[i]
+					}
 				}
-				//second argumnet is a delimiter
-				deliObj, ok := args[1].(*object.String)
-				if !ok {
-					return newError("second argument must be a String (delimiter), got %s", args[1])
+				order := make([]int, len(elements))
+				for i := range order {
+					order[i] = i
 				}
-
-				//third argument is optional data type
-				dataType := "STRING"
-				if len(args) == 3 {
-					dtObj, ok := args[2].(*object.String)
-					if !ok {
-						return newError("data type can be STRING, INT, or FLOAT")
+				var sortErr *object.Error
+				sort.SliceStable(order, func(a, b int) bool {
+					result, ok := compareObjects(keys[order[a]], keys[order[b]])
+					if !ok && sortErr == nil {
+						sortErr = newError("sort_by: keys can't be ordered: %s and %s",
+							keys[order[a]].Type(), keys[order[b]].Type())
 					}
-					dataType = dtObj.Value
+					return result < 0
+				})
+				if sortErr != nil {
+					return sortErr
 				}
 
-				content, err := os.ReadFile(filePathObj.Value)
-				if err != nil {
-					return newError("error reading file: %s", err.Error())
+				sorted := make([]object.Object, len(elements))
+				for i, idx := range order {
+					sorted[i] = elements[idx]
 				}
+				return &object.Array{Elements: sorted}
+			},
+		},
+	}
+}
 
-				lines := strings.Split(string(content), "\n")
-				var resultsRows []object.Object
-
-				for _, line := range lines {
-					// empty lines
-					// if strings.TrimSpace(line) == "" {
-					// 	resultsRows = append(resultsRows, &object.Array{Elements: []object.Object{&object.String{Value: ""}}})
-					// 	// rowFields = []object.Object{&object.String{Value: ""}}
-					// 	continue
-					// }
-
-					var rowFields []object.Object
-					fields := strings.Split(line, deliObj.Value)
-					for _, field := range fields {
-						field = strings.TrimSpace(field)
-						if field == "" {
-							rowFields = append(rowFields, NULL)
-							continue
-						}
+// the (function, collection) arguments of the higher-order builtins, the
+// collection can be an array or a set
+func functionAndElements(name string, fn, collection object.Object) (object.Object, []object.Object, *object.Error) {
+	switch fn.(type) {
+	case *object.Function, *object.Builtin:
+	default:
+		return nil, nil, newError("first argument to %s must be a function, got %s", name, fn.Type())
+	}
+	if collection.Type() != object.ARRAY_OBJ && collection.Type() != object.SET_OBJ {
+		return nil, nil, newError("second argument to %s must be an array or a set, got %s", name, collection.Type())
+	}
+	elements, _ := elementsOf(collection)
+	return fn, elements, nil
+}
 
-						var converted object.Object
-						switch dataType {
-						case "INT":
-							i, err := strconv.ParseInt(field, 10, 64)
-							if err != nil {
-								return newError("cannot convert %q to int", field)
-							}
-							converted = &object.Integer{Value: i}
-						case "FLOAT":
-							f, err := strconv.ParseFloat(field, 32)
-							if err != nil {
-								return newError("cannot convert %q to float", field)
-							}
-							converted = &object.Float{Value: float32(f)}
-
-						default:
-							converted = &object.String{Value: field}
-						}
-						rowFields = append(rowFields, converted)
+func reduce(args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, expected=2 or 3", len(args))
+	}
+	fn, elements, err := functionAndElements("reduce", args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	var result object.Object
+	if len(args) == 3 {
+		result = args[2]
+	} else {
+		if len(elements) == 0 {
+			return newError("reduce of an empty collection needs an initial value")
+		}
+		result, elements = elements[0], elements[1:]
+	}
+	for _, el := range elements {
+		result = callFunction(fn, result, el)
+		if isError(result) {
+			return result
+		}
+	}
+	return result
+}
+
+// first element fn is true for, nil when there is none
+func findElement(name string, args []object.Object) (object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, newError("wrong number of arguments. got=%d, expected=2", len(args))
+	}
+	fn, elements, err := functionAndElements(name, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	for _, el := range elements {
+		result := callFunction(fn, el)
+		if errObj, ok := result.(*object.Error); ok {
+			return nil, errObj
+		}
+		if isTruthy(result) {
+			return el, nil
+		}
+	}
+	return nil, nil
+}
+
+// builtins that need the interpreter they run in, e.g. for its I/O streams
+type contextBuiltin func(it *Interpreter, args ...object.Object) object.Object
+
+var contextBuiltins map[string]contextBuiltin
+
+func init() {
+	contextBuiltins = map[string]contextBuiltin{
+		"print": func(it *Interpreter, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprintln(it.stdout, arg.Inspect())
+			}
+			return NULL
+		},
+		"eprint": func(it *Interpreter, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprintln(it.stderr, arg.Inspect())
+			}
+			return NULL
+		},
+		// input(prompt) writes the optional prompt and reads one line
+		"input": func(it *Interpreter, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+			if len(args) == 1 {
+				prompt, ok := args[0].(*object.String)
+				if !ok {
+					return newError("argument to `input` must be STRING, got %s", args[0].Type())
+				}
+				io.WriteString(it.stdout, prompt.Value)
+			}
+			return readLine(it)
+		},
+		"read_line": func(it *Interpreter, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0", len(args))
+			}
+			return readLine(it)
+		},
+		"read_file": func(it *Interpreter, args ...object.Object) object.Object {
+			if len(args) < 2 || len(args) > 3 {
+				return newError("wrong number of arguments: got %d, expected 2 or 3", len(args))
+			}
+
+			//First argument is a file path
+			filePathObj, ok := args[0].(*object.String)
+			if !ok {
+				return newError("first argument must be a string (file path) got %s", args[0])
+			}
+			//second argumnet is a delimiter
+			deliObj, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument must be a String (delimiter), got %s", args[1])
+			}
+
+			//third argument is optional data type
+			dataType := "STRING"
+			if len(args) == 3 {
+				dtObj, ok := args[2].(*object.String)
+				if !ok {
+					return newError("data type can be STRING, INT, or FLOAT")
+				}
+				dataType = dtObj.Value
+			}
+
+			path, pathErr := it.resolvePath(filePathObj.Value)
+			if pathErr != nil {
+				return pathErr
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return newError("error reading file: %s", err.Error())
+			}
+
+			lines := strings.Split(string(content), "\n")
+			var resultsRows []object.Object
+
+			for _, line := range lines {
+				// empty lines
+				// if strings.TrimSpace(line) == "" {
+				// 	resultsRows = append(resultsRows, &object.Array{Elements: []object.Object{&object.String{Value: ""}}})
+				// 	// rowFields = []object.Object{&object.String{Value: ""}}
+				// 	continue
+				// }
+
+				var rowFields []object.Object
+				fields := strings.Split(line, deliObj.Value)
+				for _, field := range fields {
+					field = strings.TrimSpace(field)
+					if field == "" {
+						rowFields = append(rowFields, NULL)
+						continue
 					}
 
-					resultsRows = append(resultsRows, &object.Array{Elements: rowFields})
+					var converted object.Object
+					switch dataType {
+					case "INT":
+						i, err := strconv.ParseInt(field, 10, 64)
+						if err != nil {
+							return newError("cannot convert %q to int", field)
+						}
+						converted = newInteger(i)
+					case "FLOAT":
+						f, err := strconv.ParseFloat(field, 32)
+						if err != nil {
+							return newError("cannot convert %q to float", field)
+						}
+						converted = &object.Float{Value: float32(f)}
+
+					default:
+						converted = &object.String{Value: field}
+					}
+					rowFields = append(rowFields, converted)
 				}
-				return &object.Array{Elements: resultsRows}
-			},
+
+				resultsRows = append(resultsRows, &object.Array{Elements: rowFields})
+			}
+			return &object.Array{Elements: resultsRows}
 		},
+		"getenv": func(it *Interpreter, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `getenv` must be STRING, got %s", args[0].Type())
+			}
+			value, ok := os.LookupEnv(name.Value)
+			if !ok {
+				return NULL
+			}
+			return &object.String{Value: value}
+		},
+		// results that can outgrow their arguments, see arrays.go and strings.go
+		"range":     builtinRange,
+		"fill":      builtinFill,
+		"join":      builtinJoin,
+		"replace":   builtinReplace,
+		"format":    builtinFormat,
+		"repeat":    builtinRepeat,
+		"pad_left":  builtinPadLeft,
+		"pad_right": builtinPadRight,
+		// file system, see files.go
+		"read_text":   builtinReadText,
+		"read_lines":  builtinReadLines,
+		"write_file":  builtinWriteFile,
+		"append_file": builtinAppendFile,
+		"file_exists": builtinFileExists,
+		"list_dir":    builtinListDir,
+		"mkdir":       builtinMkdir,
+		"remove":      builtinRemove,
+		// random numbers, see random.go
+		"seed":     builtinSeed,
+		"rand":     builtinRand,
+		"rand_int": builtinRandInt,
+		"choice":   builtinChoice,
+		"shuffle":  builtinShuffle,
+	}
+}
+
+// read one line from stdin without the line ending, NULL once the input is exhausted
+func readLine(it *Interpreter) object.Object {
+	line, err := it.stdin.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return newError("error reading input: %s", err.Error())
+	}
+	if err == io.EOF && line == "" {
+		return NULL
 	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return &object.String{Value: line}
 }
 
 // GetBuiltinWithGetter returns the builtinWithGetter map.
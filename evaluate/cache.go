@@ -0,0 +1,35 @@
+package evaluate
+
+import (
+	"github.com/JWSch4fer/interpreter/ast"
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+// small integers are shared, see object.NewInteger
+func newInteger(value int64) *object.Integer {
+	return object.NewInteger(value)
+}
+
+// literals always evaluate to the same value, the parser builds their
+// object (see parseStringLiteral) and we reuse it. Trees built by hand
+// have no object yet and get a new one, the node is never written to.
+func evalIntegerLiteral(node *ast.IntegerLiteral) object.Object {
+	if obj, ok := node.Cached.(*object.Integer); ok {
+		return obj
+	}
+	return newInteger(node.Value)
+}
+
+func evalFloatLiteral(node *ast.FloatLiteral) object.Object {
+	if obj, ok := node.Cached.(*object.Float); ok {
+		return obj
+	}
+	return &object.Float{Value: node.Value}
+}
+
+func evalStringLiteral(node *ast.StringLiteral) object.Object {
+	if obj, ok := node.Cached.(*object.String); ok {
+		return obj
+	}
+	return &object.String{Value: node.Value}
+}
@@ -15,7 +15,31 @@ Moving callUserFunction into its own file delays its binding until runtime,
 which decouples these initialization-time dependencies.
 */
 func callUserFunction(fn *object.Function, args []object.Object) object.Object {
+	if err := checkArgumentCount(fn, args); err != nil {
+		return err
+	}
+	it := interpreterOf(fn.Env)
+	if err := it.enter(); err != nil {
+		return err
+	}
+	defer it.leave()
+	if it.guard != nil {
+		if err := it.chargeBytes(environmentSize); err != nil {
+			return err
+		}
+	}
 	extendedEnv := extendFunctionEnv(fn, args)
 	evaluated := Eval(fn.Body, extendedEnv)
 	return unwrapReturnValue(evaluated)
 }
+
+// call a user function or a builtin from inside a builtin (map, filter, ...)
+func callFunction(fn object.Object, args ...object.Object) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		return callUserFunction(fn, args)
+	case *object.Builtin:
+		return fn.Fn(args...)
+	}
+	return newError("not a function: %s", fn.Type())
+}
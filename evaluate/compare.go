@@ -0,0 +1,182 @@
+package evaluate
+
+import (
+	"math"
+	"strings"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+objectsEqual is what == means for every pair of values. Numbers compare by
+value (see numbersEqual), strings and booleans by content, null only equals
+null, arrays are equal when their elements are equal in order and hashes
+when they hold equal values under the same keys in any order, sets when
+they have the same elements in any order. Functions and builtins are only
+equal to themselves. Values of different types are never equal.
+*/
+func objectsEqual(left, right object.Object) bool {
+	return deepEqual(left, right, nil)
+}
+
+// hashes can contain themselves, pairs of hashes being compared further
+// up are taken as equal so the comparison ends
+type comparing map[[2]*object.Hash]bool
+
+func deepEqual(left, right object.Object, seen comparing) bool {
+	switch l := left.(type) {
+	case *object.Integer, *object.Float:
+		return numbersEqual(left, right)
+	case *object.String:
+		r, ok := right.(*object.String)
+		return ok && l.Value == r.Value
+	case *object.Boolean:
+		r, ok := right.(*object.Boolean)
+		return ok && l.Value == r.Value
+	case *object.Null:
+		return right.Type() == object.NULL_OBJ
+	case *object.Array:
+		r, ok := right.(*object.Array)
+		if !ok || len(l.Elements) != len(r.Elements) {
+			return false
+		}
+		for i := range l.Elements {
+			if !deepEqual(l.Elements[i], r.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		r, ok := right.(*object.Hash)
+		if !ok || l.Len() != r.Len() {
+			return false
+		}
+		if l == r || seen[[2]*object.Hash{l, r}] {
+			return true
+		}
+		if seen == nil {
+			seen = make(comparing)
+		}
+		seen[[2]*object.Hash{l, r}] = true
+		for _, pair := range l.Pairs() {
+			other, ok := r.Get(pair.Key.(object.Hashable))
+			if !ok || !deepEqual(pair.Value, other.Value, seen) {
+				return false
+			}
+		}
+		return true
+	case *object.Set:
+		r, ok := right.(*object.Set)
+		if !ok || l.Len() != r.Len() {
+			return false
+		}
+		for _, el := range l.Elements() {
+			if !r.Has(el.(object.Hashable)) {
+				return false
+			}
+		}
+		return true
+	}
+	return left == right
+}
+
+/*
+compareObjects orders two values for < and >: numbers by value (integers
+and floats exactly, without rounding the integer to a float), strings
+byte by byte and arrays element by element, a shorter array comes first
+when it is a prefix of the longer one. ok is false when the values have
+no order, like null, NaN or a number and a string.
+*/
+func compareObjects(left, right object.Object) (result int, ok bool) {
+	switch l := left.(type) {
+	case *object.Integer:
+		switch r := right.(type) {
+		case *object.Integer:
+			return compareInts(l.Value, r.Value), true
+		case *object.Float:
+			return compareIntFloat(l.Value, float64(r.Value))
+		}
+	case *object.Float:
+		switch r := right.(type) {
+		case *object.Integer:
+			result, ok := compareIntFloat(r.Value, float64(l.Value))
+			return -result, ok
+		case *object.Float:
+			return compareFloats(float64(l.Value), float64(r.Value))
+		}
+	case *object.String:
+		if r, isString := right.(*object.String); isString {
+			return strings.Compare(l.Value, r.Value), true
+		}
+	case *object.Array:
+		r, isArray := right.(*object.Array)
+		if !isArray {
+			return 0, false
+		}
+		for i := 0; i < len(l.Elements) && i < len(r.Elements); i++ {
+			result, ok := compareObjects(l.Elements[i], r.Elements[i])
+			if !ok || result != 0 {
+				return result, ok
+			}
+		}
+		return compareInts(int64(len(l.Elements)), int64(len(r.Elements))), true
+	}
+	return 0, false
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// NaN is neither smaller nor larger than anything, ok is false for it
+func compareFloats(a, b float64) (int, bool) {
+	switch {
+	case math.IsNaN(a) || math.IsNaN(b):
+		return 0, false
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	}
+	return 0, true
+}
+
+// order an integer and a float without converting the integer, which
+// would round integers above 2^53
+func compareIntFloat(i int64, f float64) (int, bool) {
+	switch {
+	case math.IsNaN(f):
+		return 0, false
+	case f >= math.MaxInt64: // 2^63, above every integer
+		return -1, true
+	case f < math.MinInt64:
+		return 1, true
+	}
+	whole := math.Trunc(f)
+	if result := compareInts(i, int64(whole)); result != 0 {
+		return result, true
+	}
+	// i is the whole part of f, the fraction decides
+	return compareFloats(0, f-whole)
+}
+
+func evalArrayInfixExpression(operator string, left, right object.Object) object.Object {
+	if operator != "<" && operator != ">" {
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	result, ok := compareObjects(left, right)
+	if !ok {
+		return newError("arrays with elements that can't be ordered: %s %s %s",
+			left.Inspect(), operator, right.Inspect())
+	}
+	if operator == "<" {
+		return nativeBoolToBooleanObject(result < 0)
+	}
+	return nativeBoolToBooleanObject(result > 0)
+}
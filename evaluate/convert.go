@@ -0,0 +1,190 @@
+package evaluate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+ToObject converts a Go value into its representation in the language.
+
+nil becomes NULL, bools/ints/uints/floats/strings map onto the matching
+primitive objects, slices and arrays become arrays, maps become hashes
+(their keys must convert to a hashable object) and a func with the
+object.BuiltinFunction signature becomes a builtin. Values that are
+already objects are returned unchanged. Unsigned values above the largest
+INTEGER are an error. A map that contains itself becomes a hash that
+contains itself, a slice can't: arrays never change, so the language has
+no arrays that contain themselves and slices doing so are an error.
+*/
+func ToObject(value any) (object.Object, error) {
+	return toObject(value, map[visit]object.Object{})
+}
+
+// a slice, map or pointer being converted, so values that contain
+// themselves are converted once
+type visit struct {
+	ptr    uintptr
+	length int
+	typ    reflect.Type
+}
+
+func toObject(value any, converted map[visit]object.Object) (object.Object, error) {
+	switch v := value.(type) {
+	case nil:
+		return NULL, nil
+	case object.Object:
+		return v, nil
+	case bool:
+		return nativeBoolToBooleanObject(v), nil
+	case string:
+		return &object.String{Value: v}, nil
+	case func(args ...object.Object) object.Object:
+		return &object.Builtin{Fn: v}, nil
+	case object.BuiltinFunction:
+		return &object.Builtin{Fn: v}, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return newInteger(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("cannot convert %d to an object: too large for an INTEGER", rv.Uint())
+		}
+		return newInteger(int64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: float32(rv.Float())}, nil
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+	case reflect.Bool:
+		return nativeBoolToBooleanObject(rv.Bool()), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, rv.Len())
+		if rv.Kind() == reflect.Slice && rv.Len() > 0 {
+			v := visit{rv.Pointer(), rv.Len(), rv.Type()}
+			if _, ok := converted[v]; ok {
+				return nil, fmt.Errorf("cannot convert %T to an object: it contains itself", value)
+			}
+			converted[v] = nil
+			defer delete(converted, v)
+		}
+		for i := range elements {
+			el, err := toObject(rv.Index(i).Interface(), converted)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = el
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return object.NewHash(), nil
+		}
+		v := visit{rv.Pointer(), 0, rv.Type()}
+		if obj, ok := converted[v]; ok {
+			return obj, nil
+		}
+		hash := object.NewHash()
+		converted[v] = hash
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := toObject(iter.Key().Interface(), converted)
+			if err != nil {
+				return nil, err
+			}
+			hashKey, ok := object.AsHashable(key)
+			if !ok {
+				return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+			}
+			val, err := toObject(iter.Value().Interface(), converted)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(hashKey, val)
+		}
+		return hash, nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return NULL, nil
+		}
+		if rv.Kind() == reflect.Interface {
+			return toObject(rv.Elem().Interface(), converted)
+		}
+		// like slices, a pointer reached again while its target is
+		// converted is a cycle
+		v := visit{rv.Pointer(), 0, rv.Type()}
+		if _, ok := converted[v]; ok {
+			return nil, fmt.Errorf("cannot convert %T to an object: it refers to itself", value)
+		}
+		converted[v] = nil
+		defer delete(converted, v)
+		return toObject(rv.Elem().Interface(), converted)
+	}
+
+	return nil, fmt.Errorf("cannot convert %T to an object", value)
+}
+
+/*
+FromObject converts an object back into a plain Go value.
+
+INTEGER -> int64, FLOAT -> float64, STRING -> string, BOOLEAN -> bool,
+NULL -> nil, ARRAY -> []any and HASH -> map[any]any. Functions, builtins
+and anything else without a Go equivalent are returned as the object.
+Array and hash keys can't be map keys in Go, the key objects themselves
+are used instead. A collection that contains itself converts to a slice or map
+that contains itself.
+*/
+func FromObject(obj object.Object) any {
+	return fromObject(obj, map[object.Object]any{})
+}
+
+func fromObject(obj object.Object, converted map[object.Object]any) any {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return float64(obj.Value)
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
+		return nil
+	case *object.Array:
+		if values, ok := converted[obj]; ok {
+			return values
+		}
+		values := make([]any, len(obj.Elements))
+		converted[obj] = values
+		for i, el := range obj.Elements {
+			values[i] = fromObject(el, converted)
+		}
+		return values
+	case *object.Hash:
+		if values, ok := converted[obj]; ok {
+			return values
+		}
+		values := make(map[any]any, obj.Len())
+		converted[obj] = values
+		for _, pair := range obj.Pairs() {
+			values[fromKey(pair.Key, converted)] = fromObject(pair.Value, converted)
+		}
+		return values
+	default:
+		return obj
+	}
+}
+
+// a hash key as a Go map key
+func fromKey(key object.Object, converted map[object.Object]any) any {
+	switch key.(type) {
+	case *object.Array, *object.Hash:
+		return key
+	}
+	return fromObject(key, converted)
+}
@@ -3,6 +3,7 @@ package evaluate
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/JWSch4fer/interpreter/ast"
 	"github.com/JWSch4fer/interpreter/object"
@@ -24,11 +25,21 @@ func isError(obj object.Object) bool {
 }
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	it := interpreterOf(env)
+	if it.guard != nil {
+		if err := it.step(); err != nil {
+			return err
+		}
+	}
+
 	switch node := node.(type) {
 
 	//special case to exit interactive mode
 	//if the user passes exit we don't need to check anything else
 	case *ast.ExitExpression:
+		if err := it.requireCapability("exit", CapExit); err != nil {
+			return err
+		}
 		os.Exit(0)
 
 	//Statements
@@ -42,7 +53,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return it.track(evalPrefixExpression(node.Operator, right))
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -53,6 +64,22 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		bounds := make([]object.Object, 3)
+		for i, exp := range []ast.Expression{node.Start, node.End, node.Step} {
+			if exp == nil {
+				continue
+			}
+			bounds[i] = Eval(exp, env)
+			if isError(bounds[i]) {
+				return bounds[i]
+			}
+		}
+		return it.track(evalSliceExpression(left, bounds[0], bounds[1], bounds[2]))
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
 		right := Eval(node.Right, env)
@@ -62,7 +89,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return it.track(evalInfixExpression(node.Operator, left, right))
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
@@ -84,9 +111,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
-		return &object.Array{Elements: elements}
+		return it.track(&object.Array{Elements: elements})
 	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+		return it.track(evalHashLiteral(node, env))
 		// Add this new case in your Eval function's switch statement:
 	case *ast.IndexAssignmentStatement:
 		return evalHashAssignment(node, env)
@@ -95,11 +122,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.NULL:
 		return NULL
 	case *ast.StringLiteral:
-		return &object.String{Value: node.Value}
+		return evalStringLiteral(node)
 	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
+		return evalIntegerLiteral(node)
 	case *ast.FloatLiteral:
-		return &object.Float{Value: node.Value}
+		return evalFloatLiteral(node)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.CommentLiteral:
@@ -119,7 +146,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return applyFunction(function, args, env)
 	}
 
 	return nil
@@ -161,7 +188,7 @@ func evalHashAssignment(node *ast.IndexAssignmentStatement, env *object.Environm
 		return keyObj
 	}
 	// Check that the key is hashable.
-	hashKey, ok := keyObj.(object.Hashable)
+	hashKey, ok := object.AsHashable(keyObj)
 	if !ok {
 		return newError("unusable as hash key: %s", keyObj.Type())
 	}
@@ -172,7 +199,10 @@ func evalHashAssignment(node *ast.IndexAssignmentStatement, env *object.Environm
 	}
 	// Update the hash: set the new value for the key.
 	hash := hashObj.(*object.Hash)
-	hash.Pairs[hashKey.HashKey()] = object.HashPair{Key: keyObj, Value: val}
+	if hash.Frozen() {
+		return newError("cannot assign to a frozen hash")
+	}
+	hash.Set(hashKey, val)
 	return val
 }
 
@@ -196,6 +226,8 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -203,32 +235,152 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	}
 }
 
+// negative indices count from the end, -1 is the last element
+func normalizeIndex(idx int64, length int) (int64, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+	return idx, idx >= 0 && idx < int64(length)
+}
+
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
 
-	if idx < 0 || idx > max {
+	if !ok {
 		return NULL
 	}
 	return arrayObject.Elements[idx]
 }
 
-func applyFunction(df object.Object, args []object.Object) object.Object {
+// strings are indexed by character, not by byte
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(runes))
+
+	if !ok {
+		return NULL
+	}
+	return &object.String{Value: string(runes[idx])}
+}
+
+/*
+evalSliceExpression follows Python: a[start:end:step] takes every step-th
+element from start up to but not including end. Negative bounds count from
+the end, bounds past either end are clamped, and missing (nil) bounds
+cover the whole array or string in the direction of step.
+*/
+func evalSliceExpression(left, start, end, step object.Object) object.Object {
+	var length int
+	var runes []rune
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		runes = []rune(left.Value)
+		length = len(runes)
+	default:
+		return newError("slice operator not supported %s", left.Type())
+	}
+
+	indices, err := sliceIndices(length, start, end, step)
+	if err != nil {
+		return err
+	}
+
+	if arr, ok := left.(*object.Array); ok {
+		elements := make([]object.Object, len(indices))
+		for i, idx := range indices {
+			elements[i] = arr.Elements[idx]
+		}
+		return &object.Array{Elements: elements}
+	}
+	sliced := make([]rune, len(indices))
+	for i, idx := range indices {
+		sliced[i] = runes[idx]
+	}
+	return &object.String{Value: string(sliced)}
+}
+
+func sliceIndices(length int, start, end, step object.Object) ([]int, *object.Error) {
+	bound := func(obj object.Object, name string, missing int64) (int64, *object.Error) {
+		if obj == nil || obj == NULL {
+			return missing, nil
+		}
+		integer, ok := obj.(*object.Integer)
+		if !ok {
+			return 0, newError("slice %s must be INTEGER, got %s", name, obj.Type())
+		}
+		return integer.Value, nil
+	}
+
+	by, err := bound(step, "step", 1)
+	if err != nil {
+		return nil, err
+	}
+	if by == 0 {
+		return nil, newError("slice step cannot be zero")
+	}
+
+	// going backwards the first index is the last element and -1 stands
+	// for "before the first element", which can't be written as a bound
+	n := int64(length)
+	lower, upper := int64(0), n
+	from, to := int64(0), n
+	if by < 0 {
+		lower, upper = -1, n-1
+		from, to = n-1, -1
+	}
+	clamp := func(obj object.Object, name string, missing int64) (int64, *object.Error) {
+		if obj == nil || obj == NULL {
+			return missing, nil
+		}
+		v, err := bound(obj, name, missing)
+		if err != nil {
+			return 0, err
+		}
+		if v < 0 {
+			v += n
+		}
+		return max(lower, min(v, upper)), nil
+	}
+
+	if from, err = clamp(start, "start", from); err != nil {
+		return nil, err
+	}
+	if to, err = clamp(end, "end", to); err != nil {
+		return nil, err
+	}
+
+	// counting first keeps a huge step from overflowing the index
+	indices := make([]int, rangeLength(from, to, by))
+	for k := range indices {
+		indices[k] = int(from + int64(k)*by)
+	}
+	return indices, nil
+}
+
+func applyFunction(df object.Object, args []object.Object, env *object.Environment) object.Object {
 
 	switch df := df.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(df, args)
-		evaluated := Eval(df.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		return callUserFunction(df, args)
 	case *object.Builtin:
-		return df.Fn(args...)
+		return interpreterOf(env).track(df.Fn(args...))
 	default:
 		return newError("not a function : %s", df.Type())
 	}
 
 }
 
+// missing arguments would leave parameters unbound, extra arguments are ignored
+func checkArgumentCount(df *object.Function, args []object.Object) *object.Error {
+	if len(args) < len(df.Parameters) {
+		return newError("wrong number of arguments: got %d, want %d", len(args), len(df.Parameters))
+	}
+	return nil
+}
+
 func extendFunctionEnv(
 	df *object.Function,
 	args []object.Object,
@@ -242,6 +394,16 @@ func extendFunctionEnv(
 	return env
 }
 
+// charge newly produced values against the allocation budget of a guarded run
+func (it *Interpreter) track(obj object.Object) object.Object {
+	if it.guard != nil && !isError(obj) {
+		if err := it.charge(obj); err != nil {
+			return err
+		}
+	}
+	return obj
+}
+
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
 		return returnValue.Value
@@ -250,16 +412,31 @@ func unwrapReturnValue(obj object.Object) object.Object {
 }
 
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	if val, ok := env.Get(node.Value); ok {
+	return evalIdentifierName(node.Value, env)
+}
+
+// bindings shadow host builtins, host builtins shadow the default builtins
+func evalIdentifierName(name string, env *object.Environment) object.Object {
+	if val, ok := env.Get(name); ok {
 		return val
 	}
-	if builtin, ok := builtins[node.Value]; ok {
+	it := interpreterOf(env)
+	if builtin, ok := it.builtins[name]; ok {
 		return builtin
 	}
-	if builtin, ok := GetBuiltinWithGetter()[node.Value]; ok {
+	if builtin, ok := it.bound[name]; ok {
 		return builtin
 	}
-	return newError("identifier not found: %s", node.Value)
+	if builtin, ok := builtins[name]; ok {
+		return builtin
+	}
+	if builtin, ok := GetBuiltinWithGetter()[name]; ok {
+		return builtin
+	}
+	if constant, ok := constants[name]; ok {
+		return constant
+	}
+	return newError("identifier not found: %s", name)
 }
 
 // only check the type so that we can handle nesting
@@ -345,13 +522,12 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 // specify behaviour of minus operator
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	if right.Type() != object.INTEGER_OBJ && right.Type() != object.FLOAT_OBJ {
-		fmt.Printf("????\n")
 		return newError("unknown operator: -%s", right.Type())
 	}
 	switch right.(type) {
 	case *object.Integer:
 		value := right.(*object.Integer).Value
-		return &object.Integer{Value: -value}
+		return newInteger(-value)
 	case *object.Float:
 		value := right.(*object.Float).Value
 		return &object.Float{Value: -value}
@@ -362,6 +538,8 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 // specify behaviour of minus operator
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case operator == "in":
+		return evalInExpression(left, right)
 	//check for integers first!!!
 	case
 		left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
@@ -374,14 +552,13 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 
-	/*
-		evaluating pointers here but we only ever have one address for TRUE
-		and one adress for FALSE no matter what else happens in the language
-	*/
+	// everything else compares structurally, see compare.go
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ:
+		return evalArrayInfixExpression(operator, left, right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -390,19 +567,62 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	}
 }
 
+/*
+x in c: sets and hashes check whether x is an element/key, arrays whether
+an element equals x and strings whether x is a substring.
+*/
+func evalInExpression(el, container object.Object) object.Object {
+	switch container := container.(type) {
+	case *object.Set:
+		key, ok := object.AsHashable(el)
+		return nativeBoolToBooleanObject(ok && container.Has(key))
+	case *object.Hash:
+		key, ok := object.AsHashable(el)
+		if !ok {
+			return FALSE
+		}
+		_, found := container.Get(key)
+		return nativeBoolToBooleanObject(found)
+	case *object.Array:
+		for _, candidate := range container.Elements {
+			if objectsEqual(el, candidate) {
+				return TRUE
+			}
+		}
+		return FALSE
+	case *object.String:
+		sub, ok := el.(*object.String)
+		if !ok {
+			return newError("`in` a STRING needs a STRING on the left, got %s", el.Type())
+		}
+		return nativeBoolToBooleanObject(strings.Contains(container.Value, sub.Value))
+	}
+	return newError("operator `in` not supported for %s", container.Type())
+}
+
 func evalStringInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
-	if operator != "+" {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 
-	leftVal := left.(*object.String).Value
-	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
-
 }
 
 func getFloatValue(obj object.Object) (float32, string) {
@@ -443,9 +663,9 @@ func evalFloatInfixExpression(
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return nativeBoolToBooleanObject(numbersEqual(left, right))
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return nativeBoolToBooleanObject(!numbersEqual(left, right))
 
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
@@ -453,6 +673,32 @@ func evalFloatInfixExpression(
 	}
 }
 
+/*
+an integer equals a float only when the float holds exactly that whole
+number, the same rule hash keys follow. Converting the integer to a float
+instead would make 16777217 == 16777216.0 true.
+*/
+func numbersEqual(left, right object.Object) bool {
+	switch l := left.(type) {
+	case *object.Integer:
+		switch r := right.(type) {
+		case *object.Integer:
+			return l.Value == r.Value
+		case *object.Float:
+			v, ok := object.IntegralFloat(r.Value)
+			return ok && v == l.Value
+		}
+	case *object.Float:
+		switch r := right.(type) {
+		case *object.Float:
+			return l.Value == r.Value
+		case *object.Integer:
+			return numbersEqual(r, l)
+		}
+	}
+	return false
+}
+
 func evalIntegerInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -462,13 +708,16 @@ func evalIntegerInfixExpression(
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		return newInteger(leftVal + rightVal)
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		return newInteger(leftVal - rightVal)
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		if rightVal == 0 {
+			return newError("division by zero: %d / 0", leftVal)
+		}
+		return newInteger(leftVal / rightVal)
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		return newInteger(leftVal * rightVal)
 
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
@@ -490,15 +739,16 @@ func newError(format string, a ...any) *object.Error {
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
+		valueNode := node.Pairs[keyNode]
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
@@ -508,22 +758,21 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		return NULL
 	}
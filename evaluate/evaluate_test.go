@@ -1,8 +1,10 @@
 package evaluate
 
 import (
+	"sync"
 	"testing"
 
+	"github.com/JWSch4fer/interpreter/ast"
 	"github.com/JWSch4fer/interpreter/lexer"
 	"github.com/JWSch4fer/interpreter/object"
 	"github.com/JWSch4fer/interpreter/parser"
@@ -154,6 +156,14 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"1 == 2", false},
 		{"1 != 2", true},
 
+		// integers and floats are equal when the float is exactly that whole number
+		{"1 == 1.0", true},
+		{"1.0 == 1", true},
+		{"1 != 1.0", false},
+		{"1 == 1.5", false},
+		{"16777217 == 16777216.0", false},
+		{"2.5 == 2.5", true},
+
 		{"true == true", true},
 		{"false == false", true},
 		{"true == false", false},
@@ -163,6 +173,41 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+
+		// strings compare by content and order lexicographically
+		{`"abc" == "abc"`, true},
+		{`"abc" != "abd"`, true},
+		{`"abc" < "abd"`, true},
+		{`"b" > "abc"`, true},
+		{`"ab" < "abc"`, true},
+
+		// arrays and hashes compare structurally
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1.0, 2.0]", true},
+		{"[] == []", true},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 9]", true},
+		{`["a", "b"] < ["a", "c"]`, true},
+		{"[9007199254740993] > [9007199254740992.0]", true},
+		{"[2.5] > [2]", true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{`let h = {}; h["self"] = h; let g = {}; g["self"] = g; h == g`, true},
+
+		// null only equals null, values of different types are never equal
+		{"NULL == NULL", true},
+		{"NULL != NULL", false},
+		{"NULL == 0", false},
+		{"NULL != false", true},
+		{`1 == "1"`, false},
+		{"[1] == {}", false},
+		{"let f = df(x) { x }; f == f", true},
+		{"df(x) { x } == df(x) { x }", false},
 	}
 
 	for _, tt := range tests {
@@ -235,6 +280,76 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 	return true
 }
 
+// expected results of builtin tests that must fail with this message
+type errorMessage string
+
+// expected results compared by their printed form, for hashes, sets and
+// functions
+type inspected string
+
+/*
+testObject checks obj against the Go value it should correspond to, so
+the type of the result is checked along with its value: int (or int64),
+float64, string and bool are INTEGER, FLOAT, STRING and BOOLEAN, nil is NULL and
+[]any an ARRAY whose elements are checked the same way.
+*/
+func testObject(t *testing.T, obj object.Object, expected any) bool {
+	switch expected := expected.(type) {
+	case int:
+		return testIntegerObject(t, obj, int64(expected))
+	case int64:
+		return testIntegerObject(t, obj, expected)
+	case float64:
+		return testFloatObject(t, obj, float32(expected))
+	case string:
+		return testStringObject(t, obj, expected)
+	case bool:
+		return testBooleanObject(t, obj, expected)
+	case nil:
+		if obj != NULL {
+			t.Errorf("object is not NULL: got %T (%+v)", obj, obj)
+			return false
+		}
+		return true
+	case []any:
+		array, ok := obj.(*object.Array)
+		if !ok {
+			t.Errorf("object is not Array: got %T (%+v)", obj, obj)
+			return false
+		}
+		if len(array.Elements) != len(expected) {
+			t.Errorf("wrong number of elements: got %d, want %d", len(array.Elements), len(expected))
+			return false
+		}
+		for i, el := range array.Elements {
+			if !testObject(t, el, expected[i]) {
+				return false
+			}
+		}
+		return true
+	case errorMessage:
+		errObj, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error: got %T (%+v)", obj, obj)
+			return false
+		}
+		if errObj.Message != string(expected) {
+			t.Errorf("wrong error message: got %q, want %q", errObj.Message, expected)
+			return false
+		}
+		return true
+	case inspected:
+		if obj.Inspect() != string(expected) {
+			t.Errorf("object has wrong value: got %q, want %q", obj.Inspect(), expected)
+			return false
+		}
+		return true
+	default:
+		t.Fatalf("unsupported expected value %T", expected)
+		return false
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -271,6 +386,10 @@ func TestErrorHandling(t *testing.T) {
 			"5 + true;",
 			"type mismatch: INTEGER + BOOLEAN",
 		},
+		{
+			"let x = 0; 5 / x",
+			"division by zero: 5 / 0",
+		},
 		{
 			"5 + true; 5;",
 			"type mismatch: INTEGER + BOOLEAN",
@@ -315,6 +434,34 @@ if (10 > 1) {
 			`"Hello" - "World"`,
 			"unknown operator: STRING - STRING",
 		},
+		{
+			`{[1, {}]: 2}`,
+			"unusable as hash key: ARRAY",
+		},
+		{
+			"NULL < 1",
+			"type mismatch: NULL < INTEGER",
+		},
+		{
+			"NULL > NULL",
+			"unknown operator: NULL > NULL",
+		},
+		{
+			`[1] < ["a"]`,
+			`arrays with elements that can't be ordered: [1] < [a]`,
+		},
+		{
+			"[NAN] < [1]",
+			"arrays with elements that can't be ordered: [NaN] < [1]",
+		},
+		{
+			"[1] + [2]",
+			"unknown operator: ARRAY + ARRAY",
+		},
+		{
+			"{} < {}",
+			"unknown operator: HASH < HASH",
+		},
 	}
 
 	for _, tt := range tests {
@@ -453,6 +600,178 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestStringIndexAndSlices(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[-1]`, "o"},
+		{`"héllo"[1]`, "é"},
+		{`len("héllo")`, "5"},
+		{`"hello"[5]`, "null"},
+
+		{"[1, 2, 3, 4, 5][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][3:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:]", "[1, 2, 3, 4, 5]"},
+		{"[1, 2, 3, 4, 5][-2:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:-2]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4, 5][::2]", "[1, 3, 5]"},
+		{"[1, 2, 3, 4, 5][1::2]", "[2, 4]"},
+		{"[1, 2, 3, 4, 5][::-1]", "[5, 4, 3, 2, 1]"},
+		{"[1, 2, 3, 4, 5][3:0:-1]", "[4, 3, 2]"},
+		{"[1, 2, 3, 4, 5][-1:-4:-2]", "[5, 3]"},
+		{"[1, 2, 3][10:]", "[]"},
+		{"[1, 2, 3][-10:2]", "[1, 2]"},
+		{"[1, 2, 3][2:1]", "[]"},
+		{"let a = [1, 2, 3]; let n = 1; a[n:n + 1]", "[2]"},
+		{`"hello"[1:4]`, "ell"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"héllo"[:2]`, "hé"},
+
+		{"[1, 2, 3][1::9223372036854775807]", "[2]"},
+		{"[1, 2, 3][::-9223372036854775807]", "[3]"},
+		{"[1, 2, 3][1::-9223372036854775807 - 1]", "[2]"},
+		{"[1, 2, 3][-9223372036854775807 - 1:9223372036854775807:9223372036854775807]", "[1]"},
+		{`"abc"[::9223372036854775807]`, "a"},
+		{"[1, 2, 3][::0]", "Error: slice step cannot be zero"},
+		{`[1, 2, 3]["a":]`, "Error: slice start must be INTEGER, got STRING"},
+		{"{}[1:2]", "Error: slice operator not supported HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map(df(x) { x }, [1, "a", true, [2], {"k": 3}])`, "[1, a, true, [2], {k: 3}]"},
+		{`map(len, ["a", "bb", [1, 2, 3]])`, "[1, 2, 3]"},
+		{"map(df(x) { x * 2 }, [])", "[]"},
+		{"filter(df(x) { x > 2 }, [1, 5, 2, 4])", "[5, 4]"},
+		{"filter(df(x) { x }, [0, NULL, false, 3])", "[0, 3]"},
+		{"reduce(df(acc, x) { acc + x }, [1, 2, 3, 4])", "10"},
+		{"reduce(df(acc, x) { acc + x }, [1, 2, 3], 10)", "16"},
+		{`fold(df(acc, x) { push(acc, x * x) }, [1, 2, 3], [])`, "[1, 4, 9]"},
+		{"reduce(df(acc, x) { acc + x }, [], 0)", "0"},
+		{"any(df(x) { x > 3 }, [1, 5])", "true"},
+		{"any(df(x) { x > 3 }, [])", "false"},
+		{"all(df(x) { x > 0 }, [1, 5])", "true"},
+		{"all(df(x) { x > 1 }, [1, 5])", "false"},
+		{"find(df(x) { x > 1 }, [1, 5, 7])", "5"},
+		{"find(df(x) { x > 10 }, [1, 5, 7])", "null"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`zip([1, 2], ["a", "b"], [true, false])`, "[[1, a, true], [2, b, false]]"},
+		{`enumerate(["a", "b"])`, "[[0, a], [1, b]]"},
+		{"flat_map(df(x) { [x, x * 10] }, [1, 2])", "[1, 10, 2, 20]"},
+		{`sort_by(len, ["ccc", "a", "bb", "d"])`, "[a, d, bb, ccc]"},
+		{`sort_by(df(p) { p["age"] }, [{"age": 30}, {"age": 20.5}])`, "[{age: 20.500000}, {age: 30}]"},
+		{"sort_by(df(x) { 0 - x }, set([1, 3, 2]))", "[3, 2, 1]"},
+		{"let add = df(a, b) { a + b }; reduce(add, [1, 2, 3])", "6"},
+
+		{"map(1, [1])", "Error: first argument to map must be a function, got INTEGER"},
+		{"filter(df(x) { x }, 1)", "Error: second argument to filter must be an array or a set, got INTEGER"},
+		{"reduce(df(a, b) { a }, [])", "Error: reduce of an empty collection needs an initial value"},
+		{"map(df(x) { x + true }, [1])", "Error: type mismatch: INTEGER + BOOLEAN"},
+		{"flat_map(df(x) { x }, [1])", "Error: function passed to flat_map must return ARRAY, got INTEGER"},
+		{`sort_by(df(x) { x }, [1, "a"])`, "Error: sort_by: keys can't be ordered: STRING and INTEGER"},
+		{"map(df(x, y) { x }, [1])", "Error: wrong number of arguments: got 1, want 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = freeze({"a": 1}); h["a"] = 2`, "Error: cannot assign to a frozen hash"},
+		{`let h = freeze({"a": 1}); delete(h, "a")`, "Error: cannot delete from a frozen hash"},
+		{`let h = freeze({"a": {"b": 1}}); h["a"]["b"] = 2`, "Error: cannot assign to a frozen hash"},
+		{`let h = freeze([{"b": 1}]); h[0]["b"] = 2`, "Error: cannot assign to a frozen hash"},
+		{`let h = {"a": 1}; freeze(h); h["a"] = 2`, "Error: cannot assign to a frozen hash"},
+		{`let h = freeze({"a": 1}); let m = merge(h, {"b": 2}); m["c"] = 3; m`, "{a: 1, b: 2, c: 3}"},
+		{`let h = {}; h["self"] = h; freeze(h); is_frozen(h)`, "true"},
+		{`let h = {}; h["self"] = h; str(h)`, "{self: {...}}"},
+		{`let h = {}; let a = [h]; h["a"] = a; a`, "[{a: [...]}]"},
+		{`[is_frozen({}), is_frozen(freeze([1])), is_frozen([1]), is_frozen(1)]`, "[false, true, true, true]"},
+		{`[is_frozen([[1], {}]), is_frozen(freeze([[1], {}]))]`, "[false, true]"},
+		{`let h = {}; let a = [1, [h]]; let before = is_frozen(a); freeze(h); [before, is_frozen(a)]`, "[false, true]"},
+		{`let h = {}; let a = [h]; h["a"] = a; freeze(a); [is_frozen(h), is_frozen(a)]`, "[true, true]"},
+		{`let k = freeze({"x": 1, "y": 2}); let h = {k: "point"}; h[freeze({"y": 2, "x": 1})]`, "point"},
+		{`let h = {}; h[freeze({"a": [1, 2]})] = 1; h[freeze({"a": [1, 2]})]`, "1"},
+		{`len(set([freeze({"a": 1}), freeze({"a": 1.0})]))`, "1"},
+		{`{{"a": 1}: 1}`, "Error: unusable as hash key: HASH"},
+		{`{freeze({"f": df(x) { x }}): 1}`, "Error: unusable as hash key: HASH"},
+		{`let h = {}; h["self"] = h; freeze(h); {h: 1}`, "Error: unusable as hash key: HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"set()", "set([])"},
+		{"set([3, 1, 3, 2, 1])", "set([3, 1, 2])"},
+		{"set([1, 1.0, [1, 2], [1, 2]])", "set([1, [1, 2]])"},
+		{`set({"a": 1, "b": 2})`, "set([a, b])"},
+		{"len(set([1, 2, 2]))", "2"},
+		{"2 in set([1, 2])", "true"},
+		{"2.0 in set([1, 2])", "true"},
+		{"5 in set([1, 2])", "false"},
+		{"[1, 2] in set([[1, 2]])", "true"},
+		{"{} in set([1])", "false"},
+		{"union(set([1, 2]), set([2, 3]))", "set([1, 2, 3])"},
+		{"intersection(set([1, 2, 3]), set([3, 2, 5]))", "set([2, 3])"},
+		{"difference(set([1, 2, 3]), set([2]))", "set([1, 3])"},
+		{"set([1, 2]) == set([2, 1])", "true"},
+		{"set([1, 2]) == set([1])", "false"},
+		{"map(df(x) { x * 10 }, set([1, 2, 1]))", "[10, 20]"},
+		{"set(set([1]))", "set([1])"},
+
+		// in also works on the other collections
+		{`"b" in {"a": 1, "b": 2}`, "true"},
+		{`[2] in [1, [2]]`, "true"},
+		{`3 in [1, 2]`, "false"},
+		{`"ell" in "hello"`, "true"},
+
+		{"set([{}])", "Error: unusable as set element: HASH"},
+		{"set(1)", "Error: argument to `set` must be ARRAY, SET or HASH, got INTEGER"},
+		{"union(set(), [1])", "Error: arguments to `union` must be SET, got ARRAY"},
+		{"1 in 2", "Error: operator `in` not supported for INTEGER"},
+		{`1 in "abc"`, "Error: `in` a STRING needs a STRING on the left, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 	evaluated := testEval(input)
@@ -512,6 +831,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 
@@ -559,24 +886,34 @@ false: 6,
 	if !ok {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
-		(&object.Float{Value: 3.5}).HashKey():      7,
+	// pairs come back in the order they were written
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
+		{&object.Float{Value: 3.5}, 7},
+	}
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	for i, pair := range result.Pairs() {
+		if pair.Key.Inspect() != expected[i].key.Inspect() {
+			t.Errorf("pair %d has wrong key. expected=%s, got=%s", i, expected[i].key.Inspect(), pair.Key.Inspect())
+		}
 	}
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for _, tt := range expected {
+		pair, ok := result.Get(tt.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
+			continue
 		}
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, tt.value)
 	}
 }
 
@@ -613,6 +950,39 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`let grid = {[0, 0]: 1, [0, 1]: 2}; grid[[0, 1]]`,
+			2,
+		},
+		{
+			`let grid = {[0, 0]: 1}; grid[[1, 0]]`,
+			nil,
+		},
+		{
+			`let h = {}; h[[1, "a"]] = 3; h[[1, "a"]] = 4; h[[1, "a"]] + len(h)`,
+			5,
+		},
+		// whole number floats and integers are the same key
+		{
+			`{1: 5}[1.0]`,
+			5,
+		},
+		{
+			`{2.0: 5}[4 / 2]`,
+			5,
+		},
+		{
+			`{[1, 2]: 5}[[1.0, 2.0]]`,
+			5,
+		},
+		{
+			`let h = {1: 1}; h[0.5 * 2] = 5; h[1] * len(h)`,
+			5,
+		},
+		{
+			`{1: 5}[1.5]`,
+			nil,
+		},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -624,3 +994,45 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestSmallIntegerInterning(t *testing.T) {
+	if testEval("5 + 5") != testEval("2 * 5") {
+		t.Errorf("small integers with the same value are different objects")
+	}
+	if testEval("-128") != newInteger(-128) || testEval("1024") != newInteger(1024) {
+		t.Errorf("small integer bounds are not interned")
+	}
+	big := testEval("1024 + 1")
+	testIntegerObject(t, big, 1025)
+	if big == testEval("1025") {
+		t.Errorf("integers outside the small range should not be interned")
+	}
+}
+
+func TestLiteralCaching(t *testing.T) {
+	program := parser.New(lexer.New(`"hello"; 3.5; 99999;`)).ParseProgram()
+
+	for _, stmt := range program.Statements {
+		exp := stmt.(*ast.ExpressionStatement).Expression
+		first := Eval(exp, object.NewEnvironment())
+		second := Eval(exp, object.NewEnvironment())
+		if first != second {
+			t.Errorf("literal %s evaluated to a new object on the second run", exp.String())
+		}
+	}
+}
+
+// the parser builds literal objects, evaluating never writes to the tree,
+// so one program can be evaluated concurrently (run with -race)
+func TestConcurrentEvalOfOneProgram(t *testing.T) {
+	program := parser.New(lexer.New(`let h = {"key": 99999}; h["key"] + len("text") + 2.5`)).ParseProgram()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testFloatObject(t, Eval(program, object.NewEnvironment()), 100005.5)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,186 @@
+package evaluate
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+file builtins. Every path goes through resolvePath, so in a sandbox they
+only reach the allowed directories, and reading or writing needs
+CapFileRead or CapFileWrite (see builtinCapabilities). Errors from the
+operating system come back as error objects naming the path the script
+gave, not the one it resolved to.
+*/
+
+// read_text(path) is the whole file as one string
+func builtinReadText(it *Interpreter, args ...object.Object) object.Object {
+	path, err := pathArg(it, "read_text", args, object.STRING_OBJ)
+	if err != nil {
+		return err
+	}
+	content, err := readFile(it, "read_text", args[0], path)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: string(content)}
+}
+
+// read_lines(path) is an array of the lines without their line endings
+func builtinReadLines(it *Interpreter, args ...object.Object) object.Object {
+	path, err := pathArg(it, "read_lines", args, object.STRING_OBJ)
+	if err != nil {
+		return err
+	}
+	content, err := readFile(it, "read_lines", args[0], path)
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return &object.Array{Elements: []object.Object{}}
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return stringArray(lines)
+}
+
+// write_file(path, text) creates or replaces the file
+func builtinWriteFile(it *Interpreter, args ...object.Object) object.Object {
+	path, err := pathArg(it, "write_file", args, object.STRING_OBJ, object.STRING_OBJ)
+	if err != nil {
+		return err
+	}
+	return writeText(it, "write_file", args, path, os.O_TRUNC)
+}
+
+// append_file(path, text) adds text at the end, creating the file if needed
+func builtinAppendFile(it *Interpreter, args ...object.Object) object.Object {
+	path, err := pathArg(it, "append_file", args, object.STRING_OBJ, object.STRING_OBJ)
+	if err != nil {
+		return err
+	}
+	return writeText(it, "append_file", args, path, os.O_APPEND)
+}
+
+// write the text in the second argument to path, flag says whether to
+// replace or extend the file
+func writeText(it *Interpreter, name string, args []object.Object, path string, flag int) object.Object {
+	f, osErr := it.openForWrite(path, os.O_CREATE|os.O_WRONLY|flag)
+	if osErr != nil {
+		return fileError(name, args[0], osErr)
+	}
+	_, osErr = f.WriteString(stringArg(args, 1))
+	if closeErr := f.Close(); osErr == nil {
+		osErr = closeErr
+	}
+	if osErr != nil {
+		return fileError(name, args[0], osErr)
+	}
+	return NULL
+}
+
+func builtinFileExists(it *Interpreter, args ...object.Object) object.Object {
+	path, err := pathArg(it, "file_exists", args, object.STRING_OBJ)
+	if err != nil {
+		return err
+	}
+	_, osErr := os.Stat(path)
+	if errors.Is(osErr, fs.ErrNotExist) {
+		return FALSE
+	}
+	if osErr != nil {
+		return fileError("file_exists", args[0], osErr)
+	}
+	return TRUE
+}
+
+// list_dir(path) is an array of the names in a directory, sorted
+func builtinListDir(it *Interpreter, args ...object.Object) object.Object {
+	path, err := pathArg(it, "list_dir", args, object.STRING_OBJ)
+	if err != nil {
+		return err
+	}
+	entries, osErr := os.ReadDir(path)
+	if osErr != nil {
+		return fileError("list_dir", args[0], osErr)
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return stringArray(names)
+}
+
+// mkdir(path) creates a directory and any missing parents
+func builtinMkdir(it *Interpreter, args ...object.Object) object.Object {
+	path, err := entryArg(it, "mkdir", args)
+	if err != nil {
+		return err
+	}
+	if osErr := os.MkdirAll(path, 0o755); osErr != nil {
+		return fileError("mkdir", args[0], osErr)
+	}
+	return NULL
+}
+
+// remove(path) deletes a file, a symlink (not what it points to) or an
+// empty directory, in a sandbox the allowed directories can't be removed
+func builtinRemove(it *Interpreter, args ...object.Object) object.Object {
+	path, err := entryArg(it, "remove", args)
+	if err != nil {
+		return err
+	}
+	if it.isSandboxDir(path) {
+		return newError("remove %q: can't remove a sandbox directory", stringArg(args, 0))
+	}
+	if osErr := os.Remove(path); osErr != nil {
+		return fileError("remove", args[0], osErr)
+	}
+	return NULL
+}
+
+// the contents of a file, its size is reserved before it is read
+func readFile(it *Interpreter, name string, pathArg object.Object, path string) ([]byte, *object.Error) {
+	if info, osErr := os.Stat(path); osErr == nil {
+		if err := it.reserve(32 + info.Size()); err != nil {
+			return nil, err
+		}
+	}
+	content, osErr := os.ReadFile(path)
+	if osErr != nil {
+		return nil, fileError(name, pathArg, osErr)
+	}
+	return content, nil
+}
+
+// check the arguments and resolve the path in the first one
+func pathArg(it *Interpreter, name string, args []object.Object, types ...object.ObjectType) (string, *object.Error) {
+	if err := checkArgs(name, args, len(types), types...); err != nil {
+		return "", err
+	}
+	return it.resolvePath(stringArg(args, 0))
+}
+
+// like pathArg for a single path naming the entry itself, see resolveEntry
+func entryArg(it *Interpreter, name string, args []object.Object) (string, *object.Error) {
+	if err := checkArgs(name, args, 1, object.STRING_OBJ); err != nil {
+		return "", err
+	}
+	return it.resolveEntry(stringArg(args, 0))
+}
+
+// an error object for err with the path as the script wrote it
+func fileError(name string, path object.Object, err error) *object.Error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return newError("%s %q: %s", name, path.(*object.String).Value, err.Error())
+}
@@ -0,0 +1,54 @@
+package evaluate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return `"` + filepath.Join(dir, name) + `"` }
+	if err := os.WriteFile(filepath.Join(dir, "crlf.txt"), []byte("a\r\nb\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the steps share one interpreter and build on each other
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`file_exists(` + path("out.txt") + `)`, "false"},
+		{`write_file(` + path("out.txt") + `, "one")`, "null"},
+		{`append_file(` + path("out.txt") + `, chr(10) + "two" + chr(10))`, "null"},
+		{`read_text(` + path("out.txt") + `)`, "one\ntwo\n"},
+		{`read_lines(` + path("out.txt") + `)`, "[one, two]"},
+		{`read_lines(` + path("crlf.txt") + `)`, "[a, b]"},
+		{`file_exists(` + path("out.txt") + `)`, "true"},
+		{`write_file(` + path("out.txt") + `, "")`, "null"},
+		{`read_lines(` + path("out.txt") + `)`, "[]"},
+		{`mkdir(` + path("sub/deeper") + `)`, "null"},
+		{`list_dir(` + path("") + `)`, "[crlf.txt, out.txt, sub]"},
+		{`remove(` + path("out.txt") + `)`, "null"},
+		{`file_exists(` + path("out.txt") + `)`, "false"},
+
+		{`read_text(` + path("missing.txt") + `)`, "read_text " + path("missing.txt") + ": no such file or directory"},
+		{`remove(` + path("sub") + `)`, "remove " + path("sub") + ": directory not empty"},
+		{`write_file(` + path("x.txt") + `, 1)`, "argument 2 to `write_file` must be STRING, got INTEGER"},
+		{`list_dir()`, "wrong number of arguments. got=0, want=1"},
+	}
+
+	it := New()
+	for _, tt := range tests {
+		result, err := it.Eval(tt.input)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		} else {
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
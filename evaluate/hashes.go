@@ -0,0 +1,147 @@
+package evaluate
+
+import (
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+hash builtins. keys, values and items follow the order the keys were
+added in, like printing a hash does. delete changes the hash in place the
+way h[k] = v does (and fails on a frozen hash like it), merge and
+map_values build a new hash.
+*/
+var hashBuiltins map[string]*object.Builtin
+
+// filled in init because map_values calls back into user functions
+func init() {
+	hashBuiltins = map[string]*object.Builtin{
+		"keys": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("keys", args, 1, object.HASH_OBJ); err != nil {
+					return err
+				}
+				keys, _ := elementsOf(args[0])
+				return &object.Array{Elements: keys}
+			},
+		},
+		"values": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("values", args, 1, object.HASH_OBJ); err != nil {
+					return err
+				}
+				pairs := args[0].(*object.Hash).Pairs()
+				values := make([]object.Object, len(pairs))
+				for i, pair := range pairs {
+					values[i] = pair.Value
+				}
+				return &object.Array{Elements: values}
+			},
+		},
+		// items(h) is an array of [key, value] pairs
+		"items": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("items", args, 1, object.HASH_OBJ); err != nil {
+					return err
+				}
+				pairs := args[0].(*object.Hash).Pairs()
+				items := make([]object.Object, len(pairs))
+				for i, pair := range pairs {
+					items[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+				}
+				return &object.Array{Elements: items}
+			},
+		},
+		"has": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("has", args, 2, object.HASH_OBJ, anyArg); err != nil {
+					return err
+				}
+				key, err := hashKeyArg(args[1])
+				if err != nil {
+					return err
+				}
+				_, ok := args[0].(*object.Hash).Get(key)
+				return nativeBoolToBooleanObject(ok)
+			},
+		},
+		// get(h, k) is h[k], get(h, k, default) returns default for a missing key
+		"get": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("get", args, 2, object.HASH_OBJ, anyArg, anyArg); err != nil {
+					return err
+				}
+				key, err := hashKeyArg(args[1])
+				if err != nil {
+					return err
+				}
+				if pair, ok := args[0].(*object.Hash).Get(key); ok {
+					return pair.Value
+				}
+				if len(args) == 3 {
+					return args[2]
+				}
+				return NULL
+			},
+		},
+		// delete(h, k) removes k from h and tells whether it was there
+		"delete": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("delete", args, 2, object.HASH_OBJ, anyArg); err != nil {
+					return err
+				}
+				key, err := hashKeyArg(args[1])
+				if err != nil {
+					return err
+				}
+				hash := args[0].(*object.Hash)
+				if hash.Frozen() {
+					return newError("cannot delete from a frozen hash")
+				}
+				return nativeBoolToBooleanObject(hash.Delete(key))
+			},
+		},
+		// merge(a, b, ...) combines hashes, a key in a later hash wins but
+		// keeps the position it first appeared at
+		"merge": {
+			Fn: func(args ...object.Object) object.Object {
+				merged := object.NewHash()
+				for i, arg := range args {
+					hash, ok := arg.(*object.Hash)
+					if !ok {
+						return newError("argument %d to `merge` must be HASH, got %s", i+1, arg.Type())
+					}
+					for _, pair := range hash.Pairs() {
+						merged.Set(pair.Key.(object.Hashable), pair.Value)
+					}
+				}
+				return merged
+			},
+		},
+		// map_values(fn, h) is a hash with the same keys and fn(value) as values
+		"map_values": {
+			Fn: func(args ...object.Object) object.Object {
+				if err := checkArgs("map_values", args, 2, anyArg, object.HASH_OBJ); err != nil {
+					return err
+				}
+				mapped := object.NewHash()
+				for _, pair := range args[1].(*object.Hash).Pairs() {
+					value := callFunction(args[0], pair.Value)
+					if isError(value) {
+						return value
+					}
+					mapped.Set(pair.Key.(object.Hashable), value)
+				}
+				return mapped
+			},
+		},
+	}
+	registerBuiltins(hashBuiltins)
+}
+
+func hashKeyArg(obj object.Object) (object.Hashable, *object.Error) {
+	key, ok := object.AsHashable(obj)
+	if !ok {
+		return nil, newError("unusable as hash key: %s", obj.Type())
+	}
+	return key, nil
+}
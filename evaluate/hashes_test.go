@@ -0,0 +1,44 @@
+package evaluate
+
+import "testing"
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`keys({"b": 1, "a": 2, 3: 3})`, []any{"b", "a", 3}},
+		{`values({"b": 1, "a": 2})`, []any{1, 2}},
+		{`items({"b": 1, [1, 2]: 2})`, []any{[]any{"b", 1}, []any{[]any{1, 2}, 2}}},
+		{`keys({})`, []any{}},
+		{`has({"a": 1}, "a")`, true},
+		{`has({1: 1}, 1.0)`, true},
+		{`has({"a": 1}, "b")`, false},
+		{`get({"a": 1}, "a", 0)`, 1},
+		{`get({"a": 1}, "b", 0)`, 0},
+		{`get({"a": 1}, "b")`, nil},
+		{`let h = {"a": 1, "b": 2}; delete(h, "a")`, true},
+		{`let h = {"a": 1, "b": 2}; delete(h, "a"); h`, inspected("{b: 2}")},
+		{`let h = {"a": 1}; delete(h, "z")`, false},
+		{`let h = {"a": 1}; delete(h, "z"); h`, inspected("{a: 1}")},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, inspected("{a: 1, b: 3, c: 4}")},
+		{`merge()`, inspected("{}")},
+		{`type(merge())`, "HASH"},
+		{`let h = {"a": 1}; merge(h, {"b": 2}); h`, inspected("{a: 1}")},
+		{`map_values(df(v) { v * 10 }, {"a": 1, "b": 2})`, inspected("{a: 10, b: 20}")},
+		{`map_values(df(v) { v * 10 }, {"a": 1})["a"]`, 10},
+		{`map_values(len, {"a": "xyz"})`, inspected("{a: 3}")},
+
+		{`keys([1])`, errorMessage("argument 1 to `keys` must be HASH, got ARRAY")},
+		{`has({}, {})`, errorMessage("unusable as hash key: HASH")},
+		{`merge({}, 1)`, errorMessage("argument 2 to `merge` must be HASH, got INTEGER")},
+		{`get({})`, errorMessage("wrong number of arguments. got=1, want=2 to 3")},
+		{`map_values(df(v) { v + "x" }, {"a": 1})`, errorMessage("type mismatch: INTEGER + STRING")},
+	}
+
+	for _, tt := range tests {
+		if !testObject(t, testEval(tt.input), tt.expected) {
+			t.Errorf("for input %s", tt.input)
+		}
+	}
+}
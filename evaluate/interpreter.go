@@ -0,0 +1,243 @@
+package evaluate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/JWSch4fer/interpreter/lexer"
+	"github.com/JWSch4fer/interpreter/object"
+	"github.com/JWSch4fer/interpreter/parser"
+)
+
+/*
+Interpreter is the entry point for Go programs embedding the language.
+
+Every interpreter owns its global environment and its own set of host
+builtins, so two interpreters in the same process can expose different
+functions to their scripts. Builtins registered on an interpreter take
+precedence over the default builtins of the language.
+*/
+type Interpreter struct {
+	env      *object.Environment
+	builtins map[string]*object.Builtin
+
+	// context builtins bound to this interpreter
+	bound map[string]*object.Builtin
+
+	stdin  *bufio.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	limits  Limits
+	guard   *guard // nil unless the current run has a context or limits
+	depth   int    // nested user function calls, see enter
+	sandbox *Sandbox
+
+	rng *rand.Rand
+}
+
+func New() *Interpreter {
+	it := &Interpreter{
+		builtins: make(map[string]*object.Builtin),
+		bound:    make(map[string]*object.Builtin, len(contextBuiltins)),
+		stdin:    bufio.NewReader(os.Stdin),
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+		rng:      newRandom(rand.Uint64()),
+	}
+	for name, fn := range contextBuiltins {
+		it.bound[name] = bindBuiltin(it, name, fn)
+	}
+	it.env = object.NewEnvironment()
+	it.env.SetHost(it)
+	return it
+}
+
+func bindBuiltin(it *Interpreter, name string, fn contextBuiltin) *object.Builtin {
+	required := builtinCapabilities[name]
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if err := it.requireCapability(name, required); err != nil {
+			return err
+		}
+		return fn(it, args...)
+	}}
+}
+
+/*
+SetStreams redirects the input and output of scripts run by the
+interpreter (print, input, ...), nil leaves a stream unchanged.
+Pass a *bufio.Reader as in if the caller also reads from it so
+that both sides share one buffer.
+*/
+func (it *Interpreter) SetStreams(in io.Reader, out, errOut io.Writer) {
+	if in != nil {
+		if reader, ok := in.(*bufio.Reader); ok {
+			it.stdin = reader
+		} else {
+			it.stdin = bufio.NewReader(in)
+		}
+	}
+	if out != nil {
+		it.stdout = out
+	}
+	if errOut != nil {
+		it.stderr = errOut
+	}
+}
+
+func (it *Interpreter) Stdin() *bufio.Reader { return it.stdin }
+func (it *Interpreter) Stdout() io.Writer    { return it.stdout }
+func (it *Interpreter) Stderr() io.Writer    { return it.stderr }
+
+// ParseError is returned when the source handed to the interpreter has syntax errors
+type ParseError struct {
+	Messages []string
+}
+
+func (e *ParseError) Error() string {
+	return "parser errors:\n\t" + strings.Join(e.Messages, "\n\t")
+}
+
+// RuntimeError wraps an object.Error produced while evaluating a script
+type RuntimeError struct {
+	Message string
+}
+
+func (e *RuntimeError) Error() string { return e.Message }
+
+// expose a Go function to scripts run by this interpreter
+func (it *Interpreter) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	it.builtins[name] = &object.Builtin{Fn: fn}
+}
+
+// bind a global variable, Go values are converted with ToObject
+func (it *Interpreter) SetGlobal(name string, value any) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+	it.env.Set(name, obj)
+	return nil
+}
+
+func (it *Interpreter) Global(name string) (object.Object, bool) {
+	return it.env.Get(name)
+}
+
+// Env returns the global environment scripts are evaluated in
+func (it *Interpreter) Env() *object.Environment {
+	return it.env
+}
+
+// parse and evaluate source in the global environment of the interpreter
+func (it *Interpreter) Eval(source string) (object.Object, error) {
+	return it.EvalContext(context.Background(), source)
+}
+
+/*
+EvalContext evaluates source like Eval but stops with a *CancelError
+once ctx is done or one of the interpreter's Limits is exceeded.
+*/
+func (it *Interpreter) EvalContext(ctx context.Context, source string) (result object.Object, err error) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Messages: p.Errors()}
+	}
+
+	defer it.guardRun(ctx)()
+	defer recoverRuntimeError(&result, &err)
+	return it.resultOf(Eval(program, it.env))
+}
+
+// call a function bound in the global environment (or a builtin) by name
+func (it *Interpreter) Call(fnName string, args ...any) (object.Object, error) {
+	return it.CallContext(context.Background(), fnName, args...)
+}
+
+func (it *Interpreter) CallContext(ctx context.Context, fnName string, args ...any) (result object.Object, err error) {
+	fn := evalIdentifierName(fnName, it.env)
+	if isError(fn) {
+		return nil, &RuntimeError{Message: fn.(*object.Error).Message}
+	}
+
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d to %s: %w", i, fnName, err)
+		}
+		objs[i] = obj
+	}
+
+	defer it.guardRun(ctx)()
+	defer recoverRuntimeError(&result, &err)
+	return it.resultOf(applyFunction(fn, objs, it.env))
+}
+
+/*
+a bug in the evaluator or in a builtin registered by the host must not
+take the host process down with it, a panic during a run is returned as
+a *RuntimeError instead
+*/
+func recoverRuntimeError(result *object.Object, err *error) {
+	if r := recover(); r != nil {
+		*result = nil
+		*err = &RuntimeError{Message: fmt.Sprintf("internal error: %v", r)}
+	}
+}
+
+// turn the result of an evaluation into the (object, error) pair of the public API
+func (it *Interpreter) resultOf(obj object.Object) (object.Object, error) {
+	if it.guard != nil && it.guard.abort != nil {
+		return nil, it.guard.abort
+	}
+	if errObj, ok := obj.(*object.Error); ok {
+		return nil, &RuntimeError{Message: errObj.Message}
+	}
+	if obj == nil {
+		return NULL, nil
+	}
+	return obj, nil
+}
+
+/*
+find the interpreter that owns an environment. A plain environment (the
+legacy Eval path) gets an interpreter of its own the first time it is
+evaluated in, so separate environments never share streams, random state
+or run limits and can be evaluated concurrently.
+*/
+func interpreterOf(env *object.Environment) *Interpreter {
+	if it, ok := env.Host().(*Interpreter); ok {
+		return it
+	}
+	it := New()
+	it.env = env
+	env.SetHost(it)
+	return it
+}
+
+// BuiltinNames lists every builtin and constant a script run by this interpreter can use, sorted
+func (it *Interpreter) BuiltinNames() []string {
+	seen := make(map[string]bool)
+	for _, set := range []map[string]*object.Builtin{it.builtins, it.bound, builtins, builtinWithGetter} {
+		for name := range set {
+			seen[name] = true
+		}
+	}
+	for name := range constants {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,411 @@
+package evaluate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+func TestInterpreterEval(t *testing.T) {
+	it := New()
+	if _, err := it.Eval("let add = df(x, y) { x + y };"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := it.Eval("add(2, 3)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, 5)
+
+	_, err = it.Eval("let = 5;")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got %T (%v)", err, err)
+	}
+
+	_, err = it.Eval("5 + true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected RuntimeError, got %T (%v)", err, err)
+	}
+	if runtimeErr.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error message: got %q", runtimeErr.Message)
+	}
+}
+
+func TestInterpreterRegisterBuiltin(t *testing.T) {
+	it := New()
+	it.RegisterBuiltin("double", func(args ...object.Object) object.Object {
+		n, ok := args[0].(*object.Integer)
+		if !ok {
+			return newError("double expects an INTEGER")
+		}
+		return newInteger(n.Value * 2)
+	})
+	// host builtins shadow the default builtins
+	it.RegisterBuiltin("len", func(args ...object.Object) object.Object {
+		return newInteger(-1)
+	})
+
+	result, err := it.Eval("double(21)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, 42)
+
+	result, err = it.Eval(`len("four")`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, -1)
+
+	// builtins are registered per interpreter
+	if _, err := New().Eval("double(21)"); err == nil {
+		t.Errorf("builtin leaked into another interpreter")
+	}
+}
+
+func TestInterpreterRecoversPanics(t *testing.T) {
+	it := New()
+	it.RegisterBuiltin("boom", func(args ...object.Object) object.Object {
+		var arr []object.Object
+		return arr[len(args)]
+	})
+
+	var runtimeErr *RuntimeError
+	if _, err := it.Eval("1 + boom(1)"); !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected RuntimeError from a panicking builtin, got %T (%v)", err, err)
+	}
+	if _, err := it.Call("boom"); !errors.As(err, &runtimeErr) {
+		t.Fatalf("expected RuntimeError from Call, got %T (%v)", err, err)
+	}
+
+	// the interpreter stays usable afterwards
+	result, err := it.Eval("1 + 2")
+	if err != nil {
+		t.Fatalf("unexpected error after panic: %s", err)
+	}
+	testIntegerObject(t, result, 3)
+}
+
+func TestInterpreterGlobalsAndCall(t *testing.T) {
+	it := New()
+	if err := it.SetGlobal("limits", map[string]any{"max": 10, "names": []string{"a", "b"}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err := it.Eval(`limits["max"] + len(limits["names"])`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, 12)
+
+	if _, err := it.Eval(`let greet = df(name, n) { name + "!" };`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	result, err = it.Call("greet", "hi", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testStringObject(t, result, "hi!")
+
+	result, err = it.Call("len", []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, 3)
+
+	if _, err := it.Call("greet", "hi"); err == nil {
+		t.Errorf("expected error calling with missing arguments")
+	}
+	if _, err := it.Call("missing"); err == nil {
+		t.Errorf("expected error calling an unknown function")
+	}
+	if err := it.SetGlobal("bad", struct{}{}); err == nil {
+		t.Errorf("expected error converting a struct")
+	}
+}
+
+// environments not created by an interpreter each get their own, so the
+// legacy Eval path can run concurrently without sharing random state
+func TestPlainEnvironments(t *testing.T) {
+	results := make([]object.Object, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = testEval(`seed(7); rand_int(0, 1000000)`)
+		}()
+	}
+	wg.Wait()
+	for _, result := range results[1:] {
+		if result.Inspect() != results[0].Inspect() {
+			t.Errorf("seeded runs differ: %s and %s", results[0].Inspect(), result.Inspect())
+		}
+	}
+
+	env := object.NewEnvironment()
+	if interpreterOf(env) != interpreterOf(env) {
+		t.Errorf("expected an environment to keep its interpreter")
+	}
+}
+
+func TestObjectConversion(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected any
+	}{
+		{nil, nil},
+		{true, true},
+		{int32(7), int64(7)},
+		{uint8(7), int64(7)},
+		{2.5, float64(2.5)},
+		{"text", "text"},
+		{[]any{1, "a", false}, []any{int64(1), "a", false}},
+		{map[string]int{"a": 1}, map[any]any{"a": int64(1)}},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("ToObject(%v) returned error: %s", tt.input, err)
+			continue
+		}
+		got := FromObject(obj)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("round trip of %v: got %#v, want %#v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestToObjectErrorsAndCycles(t *testing.T) {
+	if _, err := ToObject(uint64(math.MaxInt64) + 1); err == nil {
+		t.Errorf("expected error converting a uint64 above MaxInt64")
+	}
+	obj, err := ToObject(uint64(math.MaxInt64))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, obj, math.MaxInt64)
+
+	list := []any{1, nil}
+	list[1] = list
+	if _, err = ToObject(list); err == nil {
+		t.Errorf("expected error converting a slice that contains itself")
+	}
+
+	m := map[string]any{"a": 1}
+	m["self"] = m
+	obj, err = ToObject(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	hash := obj.(*object.Hash)
+	if pair, ok := hash.Get(&object.String{Value: "self"}); !ok || pair.Value != hash {
+		t.Errorf("expected the hash to contain itself, got %s", hash.Inspect())
+	}
+
+	p := new(any)
+	*p = p
+	if _, err := ToObject(p); err == nil {
+		t.Errorf("expected error converting a pointer to itself")
+	}
+
+	// the same slice twice is not a cycle
+	shared := []any{1}
+	obj, err = ToObject([]any{shared, shared, &shared, &shared})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if obj.Inspect() != "[[1], [1], [1], [1]]" {
+		t.Errorf("unexpected conversion of shared values: %s", obj.Inspect())
+	}
+}
+
+func TestFromObjectCompositeKeys(t *testing.T) {
+	it := New()
+	result, err := it.Eval(`let h = {[1, 2]: "a", freeze({"k": 1}): "b", 3: "c"}; h`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got, ok := FromObject(result).(map[any]any)
+	if !ok || len(got) != 3 {
+		t.Fatalf("expected a map with 3 entries, got %#v", FromObject(result))
+	}
+	if got[int64(3)] != "c" {
+		t.Errorf("got[3] = %#v, want \"c\"", got[int64(3)])
+	}
+	for key, value := range got {
+		obj, ok := key.(object.Object)
+		if !ok {
+			continue
+		}
+		want := map[string]any{"[1, 2]": "a", "{k: 1}": "b"}[obj.Inspect()]
+		if want == nil || value != want {
+			t.Errorf("unexpected entry %s: %#v", obj.Inspect(), value)
+		}
+	}
+
+	result, err = it.Eval(`let s = {}; s["self"] = s; s`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	self := FromObject(result).(map[any]any)
+	if inner, ok := self["self"].(map[any]any); !ok || len(inner) != 1 {
+		t.Errorf("expected the map to contain itself, got %#v", self["self"])
+	}
+}
+
+func TestInterpreterStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	it := New()
+	it.SetStreams(strings.NewReader("alice\r\nbob"), &stdout, &stderr)
+
+	result, err := it.Eval(`
+let name = input("name? ");
+let other = read_line();
+print("hello " + name, other);
+eprint("oops");
+read_line();
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testNullObject(t, result)
+
+	if stdout.String() != "name? hello alice\nbob\n" {
+		t.Errorf("wrong stdout: got %q", stdout.String())
+	}
+	if stderr.String() != "oops\n" {
+		t.Errorf("wrong stderr: got %q", stderr.String())
+	}
+}
+
+func TestInterpreterLimits(t *testing.T) {
+	loop := `let f = df(x) { f(x + 1) }; f(0);`
+	tests := []struct {
+		name     string
+		limits   Limits
+		input    string
+		expected error
+	}{
+		{"steps", Limits{MaxSteps: 1000}, loop, ErrStepLimit},
+		{"depth", Limits{MaxDepth: 50}, loop, ErrDepthLimit},
+		{
+			"allocations",
+			Limits{MaxAllocBytes: 1 << 20},
+			`let grow = df(s) { grow(s + s) }; grow("ab");`,
+			ErrAllocLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		it := New()
+		it.SetLimits(tt.limits)
+		_, err := it.Eval(tt.input)
+		var cancelErr *CancelError
+		if !errors.As(err, &cancelErr) {
+			t.Errorf("%s: expected CancelError, got %T (%v)", tt.name, err, err)
+			continue
+		}
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: wrong reason: got %v, want %v", tt.name, cancelErr.Reason, tt.expected)
+		}
+
+		// the limits apply per run, the interpreter stays usable
+		result, err := it.Eval("1 + 2")
+		if err != nil {
+			t.Fatalf("%s: unexpected error after limit: %s", tt.name, err)
+		}
+		testIntegerObject(t, result, 3)
+	}
+}
+
+// unbounded recursion must end in an error and not a Go stack overflow,
+// even when the run only has a timeout or no limits at all
+func TestInterpreterDefaultDepth(t *testing.T) {
+	loop := `let f = df(x) { f(x + 1) }; f(0);`
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := New().EvalContext(ctx, loop)
+	if !errors.Is(err, ErrDepthLimit) {
+		t.Errorf("expected depth limit with only a timeout, got %T (%v)", err, err)
+	}
+
+	// recursion through a builtin counts as well
+	_, err = New().EvalContext(ctx, `let f = df(x) { map(f, [x + 1]) }; f(0);`)
+	if !errors.Is(err, ErrDepthLimit) {
+		t.Errorf("expected depth limit through map, got %T (%v)", err, err)
+	}
+
+	// larger limits don't lift the cap
+	it := New()
+	it.SetLimits(Limits{MaxDepth: 10 * DefaultMaxDepth})
+	if _, err = it.Eval(loop); !errors.Is(err, ErrDepthLimit) {
+		t.Errorf("expected depth limit above DefaultMaxDepth, got %T (%v)", err, err)
+	}
+
+	evaluated := testEval(loop)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "evaluation stopped: call depth limit exceeded" {
+		t.Errorf("expected depth error without limits, got %s", evaluated.Inspect())
+	}
+
+	// the depth is back to zero after a failed run
+	if _, err := it.Eval(`let g = df(x) { if (x > 0) { g(x - 1) } else { 0 } }; g(100)`); err != nil {
+		t.Errorf("unexpected error after depth limit: %s", err)
+	}
+}
+
+func TestInterpreterLimitsAllowNormalRuns(t *testing.T) {
+	it := New()
+	it.SetLimits(Limits{MaxSteps: 100000, MaxDepth: 200, MaxAllocBytes: 1 << 20})
+	result, err := it.Eval(`
+let counter = df(x) { if (x > 100) { return x; } else { counter(x + 1); } };
+counter(0);
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, 101)
+
+	// pushing only pays for the element added, not a copy of the array
+	result, err = it.Eval(`
+len(reduce(df(arr, x) { push(arr, x) }, range(2000), []));
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testIntegerObject(t, result, 2000)
+}
+
+func TestInterpreterContextCancellation(t *testing.T) {
+	it := New()
+	// calls itself twice at every level, shallow enough for the depth cap
+	// but it never finishes
+	if _, err := it.Eval(`let f = df(x) { if (x < 60) { f(x + 1); f(x + 1) } };`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := it.EvalContext(ctx, "f(0)")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %T (%v)", err, err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = it.CallContext(ctx, "f", 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %T (%v)", err, err)
+	}
+}
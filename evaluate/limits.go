@@ -0,0 +1,251 @@
+package evaluate
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+Limits bound the work a single EvalContext/CallContext run may do,
+zero means unlimited.
+
+MaxSteps counts evaluated AST nodes, MaxDepth the number of nested
+function calls and MaxAllocBytes an estimate of the bytes allocated
+for new values (strings, arrays, hashes, numbers, scopes) over the run.
+The call depth is never allowed past DefaultMaxDepth, with or without
+limits.
+Builtins whose result can be much larger than their arguments (range,
+repeat, read_text, ...) check MaxAllocBytes before they allocate.
+*/
+type Limits struct {
+	MaxSteps      int64
+	MaxDepth      int
+	MaxAllocBytes int64
+}
+
+var (
+	ErrStepLimit  = errors.New("step limit exceeded")
+	ErrDepthLimit = errors.New("call depth limit exceeded")
+	ErrAllocLimit = errors.New("allocation limit exceeded")
+)
+
+/*
+CancelError is returned when a run was stopped from the outside, either
+because its context was cancelled or a limit was reached. Reason is the
+context error or one of the Err*Limit values, so callers can use
+errors.Is(err, context.DeadlineExceeded) or errors.Is(err, ErrStepLimit).
+*/
+type CancelError struct {
+	Reason error
+}
+
+func (e *CancelError) Error() string { return "evaluation stopped: " + e.Reason.Error() }
+func (e *CancelError) Unwrap() error { return e.Reason }
+
+// checking the context takes a lock, only do it every so many steps
+const contextCheckInterval = 256
+
+/*
+DefaultMaxDepth caps the call depth of every run, including runs without
+limits or a context. Recursing much deeper overflows the Go stack, which
+kills the host process instead of returning an error.
+*/
+const DefaultMaxDepth = 10000
+
+// state of the current guarded run
+type guard struct {
+	ctx    context.Context
+	limits Limits
+	steps  int64
+	allocs int64
+	abort  error
+}
+
+func (it *Interpreter) SetLimits(limits Limits) {
+	it.limits = limits
+}
+
+func (it *Interpreter) Limits() Limits {
+	return it.limits
+}
+
+// start a guarded run, the returned func restores the previous state
+func (it *Interpreter) guardRun(ctx context.Context) func() {
+	previous := it.guard
+	limits := it.limits
+	cancel := func() {}
+
+	// the sandbox bounds every run no matter what the caller asks for
+	if sb := it.sandbox; sb != nil {
+		if sb.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, sb.Timeout)
+		}
+		if sb.MaxAllocBytes > 0 && (limits.MaxAllocBytes == 0 || sb.MaxAllocBytes < limits.MaxAllocBytes) {
+			limits.MaxAllocBytes = sb.MaxAllocBytes
+		}
+		maxDepth := sb.MaxDepth
+		if maxDepth <= 0 {
+			maxDepth = DefaultMaxDepth
+		}
+		if limits.MaxDepth <= 0 || maxDepth < limits.MaxDepth {
+			limits.MaxDepth = maxDepth
+		}
+	}
+
+	if ctx.Done() == nil && limits == (Limits{}) {
+		it.guard = nil
+	} else {
+		if limits.MaxDepth <= 0 || limits.MaxDepth > DefaultMaxDepth {
+			limits.MaxDepth = DefaultMaxDepth
+		}
+		it.guard = &guard{ctx: ctx, limits: limits}
+	}
+	return func() {
+		cancel()
+		it.guard = previous
+	}
+}
+
+// record why the run stops, every later check fails with the same error
+func (g *guard) stop(reason error) *object.Error {
+	if g.abort == nil {
+		g.abort = &CancelError{Reason: reason}
+	}
+	return newError("%s", g.abort.Error())
+}
+
+// called for every evaluated node
+func (it *Interpreter) step() *object.Error {
+	g := it.guard
+	if g.abort != nil {
+		return g.stop(nil)
+	}
+	g.steps++
+	if g.limits.MaxSteps > 0 && g.steps > g.limits.MaxSteps {
+		return g.stop(ErrStepLimit)
+	}
+	if g.steps%contextCheckInterval == 0 {
+		if err := g.ctx.Err(); err != nil {
+			return g.stop(err)
+		}
+	}
+	return nil
+}
+
+/*
+called when entering a user function, leave must be called on return.
+The depth is counted on every run, a run without a guard fails with an
+error object once it reaches DefaultMaxDepth.
+*/
+func (it *Interpreter) enter() *object.Error {
+	g := it.guard
+	if g == nil {
+		if it.depth >= DefaultMaxDepth {
+			return newError("%s", (&CancelError{Reason: ErrDepthLimit}).Error())
+		}
+		it.depth++
+		return nil
+	}
+	if g.abort != nil {
+		return g.stop(nil)
+	}
+	if err := g.ctx.Err(); err != nil {
+		return g.stop(err)
+	}
+	if it.depth >= g.limits.MaxDepth {
+		return g.stop(ErrDepthLimit)
+	}
+	it.depth++
+	return nil
+}
+
+func (it *Interpreter) leave() {
+	it.depth--
+}
+
+// account for a value produced by the evaluator
+func (it *Interpreter) charge(obj object.Object) *object.Error {
+	return it.chargeBytes(sizeOf(obj))
+}
+
+func (it *Interpreter) chargeBytes(size int64) *object.Error {
+	g := it.guard
+	if g.abort != nil {
+		return g.stop(nil)
+	}
+	g.allocs += size
+	if g.limits.MaxAllocBytes > 0 && g.allocs > g.limits.MaxAllocBytes {
+		return g.stop(ErrAllocLimit)
+	}
+	return nil
+}
+
+// rough shallow size of a value, shared values (NULL, booleans, small ints) are free
+func sizeOf(obj object.Object) int64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		if obj.Value >= object.SmallIntMin && obj.Value <= object.SmallIntMax {
+			return 0
+		}
+		return 16
+	case *object.Float:
+		return 16
+	case *object.String:
+		return 32 + int64(len(obj.Value))
+	case *object.Array:
+		if obj.InPlace() {
+			return 32 + 16
+		}
+		return 32 + 16*int64(cap(obj.Elements))
+	case *object.Hash:
+		return 48 + 64*int64(obj.Len())
+	case *object.Boolean, *object.Null, nil:
+		return 0
+	default:
+		return 32
+	}
+}
+
+/*
+reserve is called by builtins before they build a result of about size
+bytes. It stops the run when that would exceed MaxAllocBytes or the run
+was cancelled, so the host never pays for the allocation. The bytes are
+only charged once the result is tracked.
+*/
+func (it *Interpreter) reserve(size int64) *object.Error {
+	g := it.guard
+	if g == nil {
+		return nil
+	}
+	if g.abort != nil {
+		return g.stop(nil)
+	}
+	if err := g.ctx.Err(); err != nil {
+		return g.stop(err)
+	}
+	if g.limits.MaxAllocBytes > 0 && g.allocs+size > g.limits.MaxAllocBytes {
+		return g.stop(ErrAllocLimit)
+	}
+	return nil
+}
+
+// interrupted is checked on every iteration i of long loops inside
+// builtins, which don't evaluate nodes and so never reach step
+func (it *Interpreter) interrupted(i int) *object.Error {
+	g := it.guard
+	if g == nil || i%contextCheckInterval != 0 {
+		return nil
+	}
+	if g.abort != nil {
+		return g.stop(nil)
+	}
+	if err := g.ctx.Err(); err != nil {
+		return g.stop(err)
+	}
+	return nil
+}
+
+// size charged for every new function scope
+const environmentSize = 64
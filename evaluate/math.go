@@ -0,0 +1,379 @@
+package evaluate
+
+import (
+	"math"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+// named values that are looked up like builtins
+var constants = map[string]object.Object{
+	"PI":  &object.Float{Value: math.Pi},
+	"E":   &object.Float{Value: math.E},
+	"INF": &object.Float{Value: float32(math.Inf(1))},
+	"NAN": &object.Float{Value: float32(math.NaN())},
+}
+
+/*
+math builtins take integers and floats alike. They compute with float64
+and return a float unless the result is a whole number by definition:
+abs, min, max, clamp, sum and product of integers stay integers, pow of
+two integers with a non-negative exponent is an integer, and floor, ceil,
+round and trunc always return integers. An integer result that doesn't
+fit in an int64 is an error, it never wraps around.
+*/
+var mathBuiltins = map[string]*object.Builtin{
+	"abs": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers("abs", args, 1, 1); err != nil {
+				return err
+			}
+			if i, ok := args[0].(*object.Integer); ok {
+				if i.Value == math.MinInt64 {
+					return newError("abs: the absolute value of %d can't be represented as an INTEGER", i.Value)
+				}
+				if i.Value < 0 {
+					return newInteger(-i.Value)
+				}
+				return i
+			}
+			return &object.Float{Value: float32(math.Abs(numberValue(args[0])))}
+		},
+	},
+	// min(a, b, ...) or min(collection), works for anything < can order
+	"min": {
+		Fn: func(args ...object.Object) object.Object {
+			return extreme("min", args, -1)
+		},
+	},
+	"max": {
+		Fn: func(args ...object.Object) object.Object {
+			return extreme("max", args, 1)
+		},
+	},
+	"pow": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers("pow", args, 2, 2); err != nil {
+				return err
+			}
+			base, baseIsInt := args[0].(*object.Integer)
+			exp, expIsInt := args[1].(*object.Integer)
+			if baseIsInt && expIsInt && exp.Value >= 0 {
+				result, ok := intPow(base.Value, exp.Value)
+				if !ok {
+					return newError("pow: %d to the power of %d can't be represented as an INTEGER", base.Value, exp.Value)
+				}
+				return newInteger(result)
+			}
+			return &object.Float{Value: float32(math.Pow(numberValue(args[0]), numberValue(args[1])))}
+		},
+	},
+	"sqrt":  floatFunction("sqrt", math.Sqrt),
+	"exp":   floatFunction("exp", math.Exp),
+	"sin":   floatFunction("sin", math.Sin),
+	"cos":   floatFunction("cos", math.Cos),
+	"tan":   floatFunction("tan", math.Tan),
+	"asin":  floatFunction("asin", math.Asin),
+	"acos":  floatFunction("acos", math.Acos),
+	"floor": integerFunction("floor", math.Floor),
+	"ceil":  integerFunction("ceil", math.Ceil),
+	"trunc": integerFunction("trunc", math.Trunc),
+	// round(x) rounds half away from zero to an integer, round(x, digits)
+	// keeps that many digits after the point and returns a float
+	"round": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers("round", args, 1, 2); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				return toInteger("round", math.Round(numberValue(args[0])))
+			}
+			digits, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError("argument 2 to `round` must be INTEGER, got %s", args[1].Type())
+			}
+			x := numberValue(args[0])
+			scale := math.Pow(10, float64(digits.Value))
+			scaled := x * scale
+			switch {
+			// nothing left after the point to round away
+			case math.IsInf(x, 0) || math.IsNaN(x) || math.IsInf(scale, 0) || math.IsInf(scaled, 0) || math.Abs(scaled) >= 1<<53:
+				return &object.Float{Value: float32(x)}
+			// more digits before the point than any float has
+			case scale == 0:
+				return &object.Float{Value: 0}
+			}
+			return &object.Float{Value: float32(math.Round(scaled) / scale)}
+		},
+	},
+	// atan(y, x) is the angle of the point (x, y), identifiers can't hold
+	// digits so there is no atan2
+	"atan": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers("atan", args, 1, 2); err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				return &object.Float{Value: float32(math.Atan2(numberValue(args[0]), numberValue(args[1])))}
+			}
+			return &object.Float{Value: float32(math.Atan(numberValue(args[0])))}
+		},
+	},
+	// log(x) is the natural logarithm, log(x, base) uses base
+	"log": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers("log", args, 1, 2); err != nil {
+				return err
+			}
+			result := math.Log(numberValue(args[0]))
+			if len(args) == 2 {
+				result /= math.Log(numberValue(args[1]))
+			}
+			return &object.Float{Value: float32(result)}
+		},
+	},
+	"gcd": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("gcd", args, 2, object.INTEGER_OBJ, object.INTEGER_OBJ); err != nil {
+				return err
+			}
+			a, b := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+			result := gcd(a, b)
+			if result < 0 {
+				return newError("gcd: the greatest common divisor of %d and %d can't be represented as an INTEGER", a, b)
+			}
+			return newInteger(result)
+		},
+	},
+	"lcm": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("lcm", args, 2, object.INTEGER_OBJ, object.INTEGER_OBJ); err != nil {
+				return err
+			}
+			a, b := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+			if a == 0 || b == 0 {
+				return newInteger(0)
+			}
+			lcm, ok := mulInt(a/gcd(a, b), b)
+			if !ok || lcm == math.MinInt64 {
+				return newError("lcm: the least common multiple of %d and %d can't be represented as an INTEGER", a, b)
+			}
+			if lcm < 0 {
+				lcm = -lcm
+			}
+			return newInteger(lcm)
+		},
+	},
+	// clamp(x, lo, hi) limits x to the range lo..hi
+	"clamp": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers("clamp", args, 3, 3); err != nil {
+				return err
+			}
+			x, lo, hi := args[0], args[1], args[2]
+			for _, arg := range args {
+				if f, ok := arg.(*object.Float); ok && math.IsNaN(float64(f.Value)) {
+					return newError("clamp: can't order NaN")
+				}
+			}
+			if result, _ := compareObjects(lo, hi); result > 0 {
+				return newError("clamp: lower bound %s is above upper bound %s", lo.Inspect(), hi.Inspect())
+			}
+			if result, _ := compareObjects(x, lo); result < 0 {
+				return lo
+			}
+			if result, _ := compareObjects(x, hi); result > 0 {
+				return hi
+			}
+			return x
+		},
+	},
+	"sum": {
+		Fn: func(args ...object.Object) object.Object {
+			return accumulate("sum", args, 0, addInt, func(a, b float64) float64 { return a + b })
+		},
+	},
+	"product": {
+		Fn: func(args ...object.Object) object.Object {
+			return accumulate("product", args, 1, mulInt, func(a, b float64) float64 { return a * b })
+		},
+	},
+}
+
+func init() {
+	registerBuiltins(mathBuiltins)
+}
+
+// number of arguments between min and max, all of them integers or floats
+func checkNumbers(name string, args []object.Object, min, max int) *object.Error {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return newError("wrong number of arguments. got=%d, want=%d", len(args), min)
+		}
+		return newError("wrong number of arguments. got=%d, want=%d to %d", len(args), min, max)
+	}
+	for i, arg := range args {
+		if !isNumber(arg) {
+			return newError("argument %d to `%s` must be INTEGER or FLOAT, got %s", i+1, name, arg.Type())
+		}
+	}
+	return nil
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// value of an integer or float, checkNumbers has made sure it is one
+func numberValue(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return float64(obj.(*object.Float).Value)
+}
+
+// a builtin taking one number and returning a float
+func floatFunction(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers(name, args, 1, 1); err != nil {
+				return err
+			}
+			return &object.Float{Value: float32(fn(numberValue(args[0])))}
+		},
+	}
+}
+
+// a builtin rounding one number to an integer
+func integerFunction(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkNumbers(name, args, 1, 1); err != nil {
+				return err
+			}
+			if i, ok := args[0].(*object.Integer); ok {
+				return i
+			}
+			return toInteger(name, fn(numberValue(args[0])))
+		},
+	}
+}
+
+func toInteger(name string, f float64) object.Object {
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return newError("%s: %v can't be represented as an INTEGER", name, f)
+	}
+	return newInteger(int64(f))
+}
+
+// exponentiation by squaring, ok is false when the result doesn't fit in
+// an int64
+func intPow(base, exp int64) (result int64, ok bool) {
+	result = 1
+	for exp > 0 {
+		if exp&1 == 1 {
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// a + b, ok is false when the sum overflows
+func addInt(a, b int64) (int64, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// a * b, ok is false when the product overflows
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == math.MinInt64 && b == -1) {
+		return 0, false
+	}
+	return product, true
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// smallest (direction -1) or largest (direction 1) of the arguments, or
+// of the elements when there is a single array or set argument
+func extreme(name string, args []object.Object, direction int) object.Object {
+	values := args
+	if len(args) == 1 {
+		elements, ok := elementsOf(args[0])
+		if !ok || args[0].Type() == object.HASH_OBJ {
+			return newError("argument to `%s` must be ARRAY or SET, got %s", name, args[0].Type())
+		}
+		values = elements
+	}
+	if len(values) == 0 {
+		return newError("%s of an empty collection", name)
+	}
+
+	best := values[0]
+	for _, v := range values[1:] {
+		result, ok := compareObjects(v, best)
+		if !ok {
+			return newError("%s: can't order %s and %s", name, v.Type(), best.Type())
+		}
+		if result == direction {
+			best = v
+		}
+	}
+	return best
+}
+
+// sum or product of an array or set of numbers, integer while every
+// element is an integer and an error when that integer overflows
+func accumulate(name string, args []object.Object, start int64, ints func(a, b int64) (int64, bool), floats func(a, b float64) float64) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	elements, ok := elementsOf(args[0])
+	if !ok || args[0].Type() == object.HASH_OBJ {
+		return newError("argument to `%s` must be ARRAY or SET, got %s", name, args[0].Type())
+	}
+
+	intResult, floatResult, isInt := start, float64(start), true
+	for _, el := range elements {
+		if !isNumber(el) {
+			return newError("%s: elements must be INTEGER or FLOAT, got %s", name, el.Type())
+		}
+		if i, ok := el.(*object.Integer); ok && isInt {
+			if intResult, ok = ints(intResult, i.Value); !ok {
+				return newError("%s: the result can't be represented as an INTEGER", name)
+			}
+			continue
+		}
+		if isInt {
+			floatResult, isInt = float64(intResult), false
+		}
+		floatResult = floats(floatResult, numberValue(el))
+	}
+	if isInt {
+		return newInteger(intResult)
+	}
+	return &object.Float{Value: float32(floatResult)}
+}
@@ -0,0 +1,102 @@
+package evaluate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"abs(-3)", 3},
+		{"abs(-2.5)", 2.5},
+		{"min(3, 1, 2)", 1},
+		{"max(3, 1.5, 2)", 3},
+		{"min([4, 2, 8])", 2},
+		{"max(set([4, 2, 8]))", 8},
+		{`max("apple", "pear")`, "pear"},
+		{"max(9007199254740993, 9007199254740992.0)", int64(9007199254740993)},
+		{"min(9007199254740993, 9007199254740992.0)", 9007199254740992.0},
+		{"max(9223372036854775807, 9223372036854775807.0)", 9223372036854775807.0},
+		{"min(-9223372036854775807 - 1, -9223372036854775808.0)", int64(math.MinInt64)},
+		{"max(2, 2.5)", 2.5},
+		{"min(-2, -2.5)", -2.5},
+		{"pow(2, 10)", 1024},
+		{"pow(2, 62)", int64(4611686018427387904)},
+		{"pow(-2, 63)", int64(math.MinInt64)},
+		{"pow(-1, 9223372036854775807)", -1},
+		{"pow(0, 0)", 1},
+		{"pow(2, 64)", errorMessage("pow: 2 to the power of 64 can't be represented as an INTEGER")},
+		{"pow(2, 63)", errorMessage("pow: 2 to the power of 63 can't be represented as an INTEGER")},
+		{"pow(10, 19)", errorMessage("pow: 10 to the power of 19 can't be represented as an INTEGER")},
+		{"pow(2, -1)", 0.5},
+		{"pow(2.0, 3)", 8.0},
+		{"sqrt(16)", 4.0},
+		{"floor(2.7)", 2},
+		{"floor(-2.2)", -3},
+		{"ceil(2.1)", 3},
+		{"trunc(-2.7)", -2},
+		{"round(2.5)", 3},
+		{"round(-2.5)", -3},
+		{"round(3.14159, 2)", 3.14},
+		{"round(1.5, 400)", 1.5},
+		{"round(0.0, 400)", 0.0},
+		{"round(1.5, -400)", 0.0},
+		{"round(1234.5, -2)", 1200.0},
+		{"round(INF, 2)", math.Inf(1)},
+		{"abs(-9223372036854775807)", int64(math.MaxInt64)},
+		{"sum([9223372036854775807, -1, 1])", int64(math.MaxInt64)},
+		{"lcm(-4, 6)", 12},
+		{"floor(7)", 7},
+		{"log(1)", 0.0},
+		{"log(8, 2)", 3.0},
+		{"exp(0)", 1.0},
+		{"sin(0)", 0.0},
+		{"cos(PI)", -1.0},
+		{"atan(1) * 4", math.Pi},
+		{"atan(1, -1)", 3 * math.Pi / 4},
+		{"gcd(12, 18)", 6},
+		{"gcd(-4, 6)", 2},
+		{"lcm(4, 6)", 12},
+		{"lcm(0, 6)", 0},
+		{"clamp(15, 0, 10)", 10},
+		{"clamp(-1.5, 0, 10)", 0},
+		{"clamp(5, 0, 10)", 5},
+		{"sum([1, 2, 3])", 6},
+		{"sum([1, 2.5])", 3.5},
+		{"sum([])", 0},
+		{"product([2, 3, 4])", 24},
+		{"product(set([2, 0.5]))", 1.0},
+		{"INF > 1000000", true},
+		{"NAN == NAN", false},
+		{"-INF", math.Inf(-1)},
+		{"PI", math.Pi},
+
+		{"abs(-9223372036854775807 - 1)", errorMessage("abs: the absolute value of -9223372036854775808 can't be represented as an INTEGER")},
+		{"sum([9223372036854775807, 1])", errorMessage("sum: the result can't be represented as an INTEGER")},
+		{"sum([-9223372036854775807, -2])", errorMessage("sum: the result can't be represented as an INTEGER")},
+		{"product([4611686018427387904, 4])", errorMessage("product: the result can't be represented as an INTEGER")},
+		{"lcm(9223372036854775807, 2)", errorMessage("lcm: the least common multiple of 9223372036854775807 and 2 can't be represented as an INTEGER")},
+		{"lcm(-9223372036854775807 - 1, 1)", errorMessage("lcm: the least common multiple of -9223372036854775808 and 1 can't be represented as an INTEGER")},
+		{"gcd(-9223372036854775807 - 1, 0)", errorMessage("gcd: the greatest common divisor of -9223372036854775808 and 0 can't be represented as an INTEGER")},
+		{"abs(\"a\")", errorMessage("argument 1 to `abs` must be INTEGER or FLOAT, got STRING")},
+		{"sqrt()", errorMessage("wrong number of arguments. got=0, want=1")},
+		{"min([])", errorMessage("min of an empty collection")},
+		{`max(1, "a")`, errorMessage("max: can't order STRING and INTEGER")},
+		{"floor(NAN)", errorMessage("floor: NaN can't be represented as an INTEGER")},
+		{"gcd(1.5, 2)", errorMessage("argument 1 to `gcd` must be INTEGER, got FLOAT")},
+		{"clamp(1, 5, 0)", errorMessage("clamp: lower bound 5 is above upper bound 0")},
+		{"clamp(NAN, 0, 10)", errorMessage("clamp: can't order NaN")},
+		{"max(1, NAN)", errorMessage("max: can't order FLOAT and INTEGER")},
+		{"min(NAN, NAN)", errorMessage("min: can't order FLOAT and FLOAT")},
+		{`sum([1, "a"])`, errorMessage("sum: elements must be INTEGER or FLOAT, got STRING")},
+	}
+
+	for _, tt := range tests {
+		if !testObject(t, testEval(tt.input), tt.expected) {
+			t.Errorf("for input %s", tt.input)
+		}
+	}
+}
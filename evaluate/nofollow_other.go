@@ -0,0 +1,6 @@
+//go:build !unix
+
+package evaluate
+
+// no O_NOFOLLOW here, resolvePath alone keeps writes inside the sandbox
+const openNoFollow = 0
@@ -0,0 +1,8 @@
+//go:build unix
+
+package evaluate
+
+import "syscall"
+
+// refuse to open the final component of a path when it is a symlink
+const openNoFollow = syscall.O_NOFOLLOW
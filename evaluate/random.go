@@ -0,0 +1,83 @@
+package evaluate
+
+import (
+	"math/rand/v2"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+every interpreter has its own random number generator so scripts (and
+tests) get the same numbers after seed(n) no matter what else runs in the
+process. Until a seed is set the generator starts from a random state.
+*/
+func newRandom(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// Seed resets the random number generator of the interpreter, like seed(n) in a script
+func (it *Interpreter) Seed(seed int64) {
+	it.rng = newRandom(uint64(seed))
+}
+
+// seed(n)
+func builtinSeed(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("seed", args, 1, object.INTEGER_OBJ); err != nil {
+		return err
+	}
+	it.Seed(args[0].(*object.Integer).Value)
+	return NULL
+}
+
+// rand() is a float in [0, 1)
+func builtinRand(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("rand", args, 0); err != nil {
+		return err
+	}
+	return &object.Float{Value: it.rng.Float32()}
+}
+
+// rand_int(lo, hi) is an integer from lo to hi, both included
+func builtinRandInt(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("rand_int", args, 2, object.INTEGER_OBJ, object.INTEGER_OBJ); err != nil {
+		return err
+	}
+	lo, hi := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+	if lo > hi {
+		return newError("rand_int: lower bound %d is above upper bound %d", lo, hi)
+	}
+	// the span can overflow int64, uint64 holds every possible one except
+	// the full range, which any random value covers
+	span := uint64(hi-lo) + 1
+	if span == 0 {
+		return newInteger(int64(it.rng.Uint64()))
+	}
+	return newInteger(lo + int64(it.rng.Uint64N(span)))
+}
+
+// choice(collection) is a random element of an array or set
+func builtinChoice(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("choice", args, 1, anyArg); err != nil {
+		return err
+	}
+	elements, ok := elementsOf(args[0])
+	if !ok || args[0].Type() == object.HASH_OBJ {
+		return newError("argument to `choice` must be ARRAY or SET, got %s", args[0].Type())
+	}
+	if len(elements) == 0 {
+		return newError("choice from an empty collection")
+	}
+	return elements[it.rng.IntN(len(elements))]
+}
+
+// shuffle(arr) returns the elements in random order, arr is left as it is
+func builtinShuffle(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("shuffle", args, 1, object.ARRAY_OBJ); err != nil {
+		return err
+	}
+	elements := append([]object.Object{}, args[0].(*object.Array).Elements...)
+	it.rng.Shuffle(len(elements), func(i, j int) {
+		elements[i], elements[j] = elements[j], elements[i]
+	})
+	return &object.Array{Elements: elements}
+}
@@ -0,0 +1,99 @@
+package evaluate
+
+import (
+	"testing"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+const randomScript = `
+seed(42);
+[rand(), rand_int(1, 6), rand_int(-100, 100), choice(["a", "b", "c"]), shuffle([1, 2, 3, 4, 5])]
+`
+
+func TestSeedIsReproducible(t *testing.T) {
+	first, err := New().Eval(randomScript)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	second, err := New().Eval(randomScript)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if first.Inspect() != second.Inspect() {
+		t.Errorf("same seed gave different results:\n%s\n%s", first.Inspect(), second.Inspect())
+	}
+
+	// the host API seeds the same generator
+	it := New()
+	it.Seed(42)
+	third, err := it.Eval(`[rand(), rand_int(1, 6), rand_int(-100, 100), choice(["a", "b", "c"]), shuffle([1, 2, 3, 4, 5])]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if third.Inspect() != first.Inspect() {
+		t.Errorf("Seed(42) and seed(42) differ:\n%s\n%s", third.Inspect(), first.Inspect())
+	}
+}
+
+func TestInterpretersHaveTheirOwnGenerator(t *testing.T) {
+	a, b := New(), New()
+	a.Seed(1)
+	b.Seed(1)
+	// drawing from a must not move b's generator
+	if _, err := a.Eval("rand(); rand(); rand()"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	fromA, _ := a.Eval("seed(1); rand()")
+	fromB, _ := b.Eval("rand()")
+	if fromA.Inspect() != fromB.Inspect() {
+		t.Errorf("interpreters share random state: %s != %s", fromA.Inspect(), fromB.Inspect())
+	}
+}
+
+func TestRandomBuiltins(t *testing.T) {
+	it := New()
+	it.Seed(7)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let r = rand(); r > -0.1`, "true"},
+		{`let r = rand(); r < 1.0`, "true"},
+		{`let n = rand_int(3, 3); n`, "3"},
+		{`choice(["only"])`, "only"},
+		{`choice(set([5]))`, "5"},
+		{`sort_by(df(x) { x }, shuffle([3, 1, 2]))`, "[1, 2, 3]"},
+		{`let a = [1, 2, 3]; shuffle(a); a`, "[1, 2, 3]"},
+		{`seed(1)`, "null"},
+
+		{`rand(1)`, "Error: wrong number of arguments. got=1, want=0"},
+		{`rand_int(5, 1)`, "Error: rand_int: lower bound 5 is above upper bound 1"},
+		{`choice([])`, "Error: choice from an empty collection"},
+		{`choice(1)`, "Error: argument to `choice` must be ARRAY or SET, got INTEGER"},
+		{`seed(1.5)`, "Error: argument 1 to `seed` must be INTEGER, got FLOAT"},
+	}
+
+	for _, tt := range tests {
+		result, err := it.Eval(tt.input)
+		got := ""
+		if err != nil {
+			got = "Error: " + err.Error()
+		} else {
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	for i := 0; i < 200; i++ {
+		result, err := it.Eval("rand_int(-2, 2)")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if n := result.(*object.Integer).Value; n < -2 || n > 2 {
+			t.Fatalf("rand_int(-2, 2) out of range: %d", n)
+		}
+	}
+}
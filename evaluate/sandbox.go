@@ -0,0 +1,214 @@
+package evaluate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+// Capability is something a sandboxed script has to be granted explicitly
+type Capability int
+
+const (
+	CapFileRead  Capability = 1 << iota // read files inside Sandbox.Dirs
+	CapFileWrite                        // create, change and remove files inside Sandbox.Dirs
+	CapExit                             // stop the host process with exit
+	CapEnv                              // read environment variables
+)
+
+/*
+Sandbox restricts what untrusted scripts may do to the host.
+
+Without a sandbox scripts have full access. With one, builtins needing a
+capability fail unless it is listed in Allow, file builtins only reach
+paths inside Dirs (after resolving symlinks), and every run is bounded
+by Timeout, MaxAllocBytes and MaxDepth on top of the interpreter's Limits.
+MaxDepth defaults to DefaultMaxDepth.
+*/
+type Sandbox struct {
+	Dirs          []string
+	Allow         Capability
+	Timeout       time.Duration
+	MaxAllocBytes int64
+	MaxDepth      int
+}
+
+// capabilities a context builtin needs, checked centrally before it runs
+var builtinCapabilities = map[string]Capability{
+	"read_file":   CapFileRead,
+	"read_text":   CapFileRead,
+	"read_lines":  CapFileRead,
+	"file_exists": CapFileRead,
+	"list_dir":    CapFileRead,
+	"write_file":  CapFileWrite,
+	"append_file": CapFileWrite,
+	"mkdir":       CapFileWrite,
+	"remove":      CapFileWrite,
+	"getenv":      CapEnv,
+}
+
+// SetSandbox restricts the interpreter, nil removes all restrictions
+func (it *Interpreter) SetSandbox(sb *Sandbox) {
+	it.sandbox = sb
+}
+
+func (it *Interpreter) Sandbox() *Sandbox {
+	return it.sandbox
+}
+
+func (it *Interpreter) permits(c Capability) bool {
+	return it.sandbox == nil || it.sandbox.Allow&c == c
+}
+
+func (it *Interpreter) requireCapability(name string, c Capability) *object.Error {
+	if !it.permits(c) {
+		return newError("%s is not permitted in sandbox mode", name)
+	}
+	return nil
+}
+
+/*
+resolvePath is the single gate between scripts and the file system,
+every file builtin must use it. Outside a sandbox the path is returned
+unchanged, inside one it must resolve to a location within one of the
+allowed directories.
+*/
+func (it *Interpreter) resolvePath(path string) (string, *object.Error) {
+	if it.sandbox == nil {
+		return path, nil
+	}
+
+	resolved, err := resolveSymlinks(path)
+	if err != nil {
+		return "", newError("invalid path %q: %s", path, err.Error())
+	}
+	if _, ok := it.sandboxDir(resolved); !ok {
+		return "", newError("access to %q is outside the sandbox", path)
+	}
+	return resolved, nil
+}
+
+/*
+resolveEntry is resolvePath for builtins that act on a directory entry
+itself and not on what it points to, like remove. Only the parent is
+resolved, the last part of the path is kept as given, so removing a
+symlink removes the link just like it does outside a sandbox.
+*/
+func (it *Interpreter) resolveEntry(path string) (string, *object.Error) {
+	if it.sandbox == nil {
+		return path, nil
+	}
+
+	cleaned := filepath.Clean(path)
+	name := filepath.Base(cleaned)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", newError("invalid path %q: must name a file or directory", path)
+	}
+	parent, err := resolveSymlinks(filepath.Dir(cleaned))
+	if err != nil {
+		return "", newError("invalid path %q: %s", path, err.Error())
+	}
+	resolved := filepath.Join(parent, name)
+	if _, ok := it.sandboxDir(resolved); !ok {
+		return "", newError("access to %q is outside the sandbox", path)
+	}
+	return resolved, nil
+}
+
+// the allowed directory a resolved path is in, ok is false when it is in none
+func (it *Interpreter) sandboxDir(resolved string) (string, bool) {
+	for _, dir := range it.sandbox.Dirs {
+		root, err := resolveSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// whether path, as returned by resolvePath or resolveEntry, is one of the
+// allowed directories itself
+func (it *Interpreter) isSandboxDir(path string) bool {
+	if it.sandbox == nil {
+		return false
+	}
+	root, ok := it.sandboxDir(path)
+	return ok && root == path
+}
+
+/*
+absolute path with symlinks resolved, for paths that don't exist yet the
+deepest existing parent is resolved so a link can't point a new file
+outside the sandbox. A dangling link is followed by hand: the file it
+names doesn't exist, but writing to the link would create it.
+*/
+func resolveSymlinks(path string) (string, error) {
+	return followSymlinks(path, 0)
+}
+
+// like the kernel, give up on link chains that are too long (or loop)
+const maxSymlinks = 40
+
+func followSymlinks(path string, hops int) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	missing := []string{}
+	current := abs
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if info, err := os.Lstat(current); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if hops >= maxSymlinks {
+				return "", errors.New("too many levels of symbolic links")
+			}
+			target, err := os.Readlink(current)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				// relative to the real directory of the link, not the path
+				// leading to it, or .. in the target could be cleaned away
+				dir, err := filepath.EvalSymlinks(filepath.Dir(current))
+				if err != nil {
+					return "", err
+				}
+				target = filepath.Join(dir, target)
+			}
+			return followSymlinks(filepath.Join(append([]string{target}, missing...)...), hops+1)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return abs, nil
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+		current = parent
+	}
+}
+
+/*
+openForWrite opens a path returned by resolvePath. In a sandbox the
+final component must not be a symlink: resolvePath has followed every
+link already, so one showing up now was put there in the meantime.
+*/
+func (it *Interpreter) openForWrite(path string, flag int) (*os.File, error) {
+	if it.sandbox != nil {
+		flag |= openNoFollow
+	}
+	return os.OpenFile(path, flag, 0o644)
+}
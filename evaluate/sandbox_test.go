@@ -0,0 +1,261 @@
+package evaluate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxFileAccess(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(allowed, "in.txt"), []byte("1,2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("3,4"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// a link inside the sandbox must not lead outside of it
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	it := New()
+	it.SetSandbox(&Sandbox{Dirs: []string{allowed}, Allow: CapFileRead})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{filepath.Join(allowed, "in.txt"), ""},
+		{filepath.Join(allowed, "sub", "..", "in.txt"), ""},
+		{filepath.Join(outside, "secret.txt"), "outside the sandbox"},
+		{filepath.Join(allowed, "..", filepath.Base(outside), "secret.txt"), "outside the sandbox"},
+		{filepath.Join(allowed, "escape", "secret.txt"), "outside the sandbox"},
+	}
+
+	for _, tt := range tests {
+		_, err := it.Eval(`read_text("` + tt.path + `")`)
+		if (err == nil) != (tt.expected == "") {
+			t.Errorf("read_text(%q): expected error %q, got %v", tt.path, tt.expected, err)
+		}
+		_, err = it.Eval(`read_file("` + tt.path + `", ",", "INT")`)
+		if tt.expected == "" {
+			if err != nil {
+				t.Errorf("read_file(%q) failed: %s", tt.path, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("read_file(%q): expected error containing %q, got %v", tt.path, tt.expected, err)
+		}
+	}
+
+	// new files are checked the same way, through links as well, and a
+	// dangling link must not create its target outside the sandbox
+	if err := os.Symlink(filepath.Join(outside, "pwned.txt"), filepath.Join(allowed, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("escape", "pwned-relative.txt"), filepath.Join(allowed, "dangling-relative")); err != nil {
+		t.Fatal(err)
+	}
+	it.SetSandbox(&Sandbox{Dirs: []string{allowed}, Allow: CapFileWrite})
+	if _, err := it.Eval(`write_file("` + filepath.Join(allowed, "new.txt") + `", "x")`); err != nil {
+		t.Errorf("write_file inside the sandbox failed: %s", err)
+	}
+	writes := []struct{ path, created string }{
+		{filepath.Join(outside, "new.txt"), filepath.Join(outside, "new.txt")},
+		{filepath.Join(allowed, "escape", "new.txt"), filepath.Join(outside, "new.txt")},
+		{filepath.Join(allowed, "dangling"), filepath.Join(outside, "pwned.txt")},
+		{filepath.Join(allowed, "dangling-relative"), filepath.Join(outside, "pwned-relative.txt")},
+	}
+	for _, w := range writes {
+		for _, builtin := range []string{"write_file", "append_file"} {
+			_, err := it.Eval(builtin + `("` + w.path + `", "x")`)
+			if err == nil || !strings.Contains(err.Error(), "outside the sandbox") {
+				t.Errorf("%s(%q): expected error outside the sandbox, got %v", builtin, w.path, err)
+			}
+			if _, statErr := os.Stat(w.created); statErr == nil {
+				t.Fatalf("%s(%q) created %s outside the sandbox", builtin, w.path, w.created)
+			}
+		}
+	}
+}
+
+// remove acts on the link and not on its target, like it does without a
+// sandbox, and the sandbox directory itself can't be removed
+func TestSandboxRemove(t *testing.T) {
+	dir := t.TempDir()
+	target, link := filepath.Join(dir, "target.txt"), filepath.Join(dir, "link")
+	if err := os.WriteFile(target, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	it := New()
+	it.SetSandbox(&Sandbox{Dirs: []string{dir}, Allow: CapFileWrite})
+
+	if _, err := it.Eval(`remove("` + link + `")`); err != nil {
+		t.Fatalf("remove of a link failed: %s", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Errorf("expected the link to be removed, got %v", err)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected the link target to be kept, got %v", err)
+	}
+
+	for _, path := range []string{dir, dir + string(filepath.Separator), filepath.Join(dir, "sub", "..")} {
+		_, err := it.Eval(`remove("` + path + `")`)
+		if err == nil || !strings.Contains(err.Error(), "can't remove a sandbox directory") && !strings.Contains(err.Error(), "invalid path") {
+			t.Errorf("remove(%q): expected an error, got %v", path, err)
+		}
+	}
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := it.Eval(`remove("` + dir + `")`); err == nil {
+		t.Errorf("expected an error removing the empty sandbox directory")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("sandbox directory was removed: %v", err)
+	}
+}
+
+func TestSandboxSymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Symlink(filepath.Join(dir, "b"), filepath.Join(dir, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "a"), filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	it := New()
+	it.SetSandbox(&Sandbox{Dirs: []string{dir}, Allow: CapFileWrite})
+	_, err := it.Eval(`write_file("` + filepath.Join(dir, "a") + `", "x")`)
+	if err == nil || !strings.Contains(err.Error(), "invalid path") {
+		t.Errorf("expected invalid path for a symlink loop, got %v", err)
+	}
+}
+
+func TestSandboxCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "in.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	it := New()
+	it.SetSandbox(&Sandbox{Dirs: []string{dir}})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`read_file("` + filepath.Join(dir, "in.txt") + `", ",")`, "read_file is not permitted in sandbox mode"},
+		{`read_text("` + filepath.Join(dir, "in.txt") + `")`, "read_text is not permitted in sandbox mode"},
+		{`write_file("` + filepath.Join(dir, "in.txt") + `", "y")`, "write_file is not permitted in sandbox mode"},
+		{`getenv("HOME")`, "getenv is not permitted in sandbox mode"},
+		{`exit`, "exit is not permitted in sandbox mode"},
+	}
+	for _, tt := range tests {
+		_, err := it.Eval(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+
+	it.SetSandbox(&Sandbox{Allow: CapEnv})
+	t.Setenv("SANDBOX_TEST_VALUE", "visible")
+	result, err := it.Eval(`getenv("SANDBOX_TEST_VALUE")`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	testStringObject(t, result, "visible")
+}
+
+func TestSandboxLimits(t *testing.T) {
+	it := New()
+	it.SetSandbox(&Sandbox{Timeout: 20 * time.Millisecond})
+	_, err := it.Eval(`let f = df(x) { f(x + 1) }; f(0);`)
+	var cancelErr *CancelError
+	if !errors.As(err, &cancelErr) {
+		t.Fatalf("expected CancelError from sandbox timeout, got %T (%v)", err, err)
+	}
+
+	// the stricter of the sandbox and the interpreter limits wins
+	it = New()
+	it.SetLimits(Limits{MaxAllocBytes: 1 << 30})
+	it.SetSandbox(&Sandbox{MaxAllocBytes: 1 << 16})
+	_, err = it.Eval(`let grow = df(s) { grow(s + s) }; grow("ab");`)
+	if !errors.Is(err, ErrAllocLimit) {
+		t.Fatalf("expected allocation limit from sandbox, got %T (%v)", err, err)
+	}
+}
+
+// recursion in a sandbox ends at a depth limit even when the timeout and
+// memory cap are far away
+func TestSandboxDepth(t *testing.T) {
+	loop := `let f = df(x) { f(x + 1) }; f(0);`
+	it := New()
+	it.SetSandbox(&Sandbox{Timeout: 2 * time.Second, MaxAllocBytes: 1 << 30})
+	if _, err := it.Eval(loop); !errors.Is(err, ErrDepthLimit) {
+		t.Fatalf("expected depth limit from sandbox, got %T (%v)", err, err)
+	}
+
+	// the stricter of the sandbox and the interpreter depth wins
+	count := `let f = df(x) { if (x > 0) { f(x - 1) } else { 0 } }; f(100);`
+	for _, tt := range []struct {
+		sandbox, limits int
+	}{
+		{50, 1000},
+		{1000, 50},
+		{50, 0},
+	} {
+		it := New()
+		it.SetLimits(Limits{MaxDepth: tt.limits})
+		it.SetSandbox(&Sandbox{MaxDepth: tt.sandbox})
+		if _, err := it.Eval(count); !errors.Is(err, ErrDepthLimit) {
+			t.Errorf("sandbox %d, limits %d: expected depth limit, got %T (%v)", tt.sandbox, tt.limits, err, err)
+		}
+	}
+}
+
+// builtins that build large results check the budget before allocating
+func TestSandboxLimitsLargeResults(t *testing.T) {
+	it := New()
+	it.SetSandbox(&Sandbox{Timeout: 10 * time.Millisecond, MaxAllocBytes: 1 << 20})
+	for _, input := range []string{
+		`len(range(0, 30000000))`,
+		`fill(30000000, 1)`,
+		`repeat("abcdefgh", 30000000)`,
+		`pad_left("", 30000000)`,
+		`join(fill(20000, repeat("a", 2000)))`,
+		`replace(repeat("a", 100000), "a", repeat("b", 2000))`,
+		`format(repeat("{0}", 50000), repeat("a", 2000))`,
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := it.Eval(input)
+		runtime.ReadMemStats(&after)
+		if !errors.Is(err, ErrAllocLimit) {
+			t.Errorf("%s: expected allocation limit, got %T (%v)", input, err, err)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+			t.Errorf("%s: allocated %d bytes before failing", input, allocated)
+		}
+	}
+
+	// a cancelled run stops before a builtin starts working
+	it = New()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := it.EvalContext(ctx, `len(range(0, 1000000))`); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %T (%v)", err, err)
+	}
+}
@@ -0,0 +1,362 @@
+package evaluate
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+/*
+string builtins. Like indexing and len they count characters, not bytes,
+so index_of and pad_left/pad_right agree with s[i] and len(s). join,
+replace, format, repeat and pad_left/pad_right can build strings far
+longer than their arguments, they work out the length of the result and
+check the interpreter's allocation budget first.
+*/
+var stringBuiltins = map[string]*object.Builtin{
+	// split(s) splits on runs of whitespace, split(s, sep) on sep
+	"split": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("split", args, 1, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+			var parts []string
+			if len(args) == 1 {
+				parts = strings.Fields(stringArg(args, 0))
+			} else {
+				parts = strings.Split(stringArg(args, 0), stringArg(args, 1))
+			}
+			return stringArray(parts)
+		},
+	},
+	// trim(s) strips whitespace, trim(s, chars) strips any of chars
+	"trim": {
+		Fn: func(args ...object.Object) object.Object {
+			return trim("trim", args, strings.TrimSpace, strings.Trim)
+		},
+	},
+	"trim_left": {
+		Fn: func(args ...object.Object) object.Object {
+			return trim("trim_left", args, func(s string) string {
+				return strings.TrimLeft(s, " \t\r\n\v\f")
+			}, strings.TrimLeft)
+		},
+	},
+	"trim_right": {
+		Fn: func(args ...object.Object) object.Object {
+			return trim("trim_right", args, func(s string) string {
+				return strings.TrimRight(s, " \t\r\n\v\f")
+			}, strings.TrimRight)
+		},
+	},
+	"upper": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("upper", args, 1, object.STRING_OBJ); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToUpper(stringArg(args, 0))}
+		},
+	},
+	"lower": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("lower", args, 1, object.STRING_OBJ); err != nil {
+				return err
+			}
+			return &object.String{Value: strings.ToLower(stringArg(args, 0))}
+		},
+	},
+	"starts_with": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("starts_with", args, 2, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasPrefix(stringArg(args, 0), stringArg(args, 1)))
+		},
+	},
+	"ends_with": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("ends_with", args, 2, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasSuffix(stringArg(args, 0), stringArg(args, 1)))
+		},
+	},
+	"chars": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("chars", args, 1, object.STRING_OBJ); err != nil {
+				return err
+			}
+			runes := []rune(stringArg(args, 0))
+			chars := make([]string, len(runes))
+			for i, r := range runes {
+				chars[i] = string(r)
+			}
+			return stringArray(chars)
+		},
+	},
+	// code point of a single character
+	"ord": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("ord", args, 1, object.STRING_OBJ); err != nil {
+				return err
+			}
+			r, ok := singleRune(stringArg(args, 0))
+			if !ok {
+				return newError("argument to `ord` must be a single character, got %q", stringArg(args, 0))
+			}
+			return newInteger(int64(r))
+		},
+	},
+	"chr": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("chr", args, 1, object.INTEGER_OBJ); err != nil {
+				return err
+			}
+			code := args[0].(*object.Integer).Value
+			if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+				return newError("argument to `chr` is not a valid character code: %d", code)
+			}
+			return &object.String{Value: string(rune(code))}
+		},
+	},
+}
+
+func init() {
+	registerBuiltins(stringBuiltins)
+}
+
+// the value of argument i, checkArgs has made sure it is a string
+func stringArg(args []object.Object, i int) string {
+	return args[i].(*object.String).Value
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		elements[i] = &object.String{Value: v}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func singleRune(s string) (rune, bool) {
+	r, size := utf8.DecodeRuneInString(s)
+	return r, s != "" && size == len(s)
+}
+
+func trim(name string, args []object.Object, space func(string) string, cut func(string, string) string) object.Object {
+	if err := checkArgs(name, args, 1, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return &object.String{Value: space(stringArg(args, 0))}
+	}
+	return &object.String{Value: cut(stringArg(args, 0), stringArg(args, 1))}
+}
+
+// join(arr, sep) joins the elements, elements that aren't strings are
+// written the way print shows them
+// the string half of contains, the builtin itself is in arrays.go
+func stringContains(args ...object.Object) object.Object {
+	if err := checkArgs("contains", args, 2, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+	return nativeBoolToBooleanObject(strings.Contains(stringArg(args, 0), stringArg(args, 1)))
+}
+
+// position of the first occurrence of sub, -1 when there is none. The
+// string half of index_of, the builtin itself is in arrays.go
+func stringIndexOf(args ...object.Object) object.Object {
+	if err := checkArgs("index_of", args, 2, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+	s := stringArg(args, 0)
+	idx := strings.Index(s, stringArg(args, 1))
+	if idx < 0 {
+		return newInteger(-1)
+	}
+	return newInteger(int64(utf8.RuneCountInString(s[:idx])))
+}
+
+func builtinJoin(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("join", args, 1, object.ARRAY_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+	sep := ""
+	if len(args) == 2 {
+		sep = stringArg(args, 1)
+	}
+	elements := args[0].(*object.Array).Elements
+	parts := make([]string, len(elements))
+	size := int64(0)
+	for i, el := range elements {
+		// printing a nested value allocates, so the budget is checked as
+		// the parts come in and not just once at the end
+		parts[i] = el.Inspect()
+		size += int64(len(parts[i]))
+		if i > 0 {
+			size += int64(len(sep))
+		}
+		if size > maxResultBytes {
+			return newError("join: result would be longer than %d bytes", maxResultBytes)
+		}
+		if err := it.reserve(32 + size); err != nil {
+			return err
+		}
+	}
+	return &object.String{Value: strings.Join(parts, sep)}
+}
+
+// replace(s, old, new) replaces every occurrence, replace(s, old, new, n) the first n
+func builtinReplace(it *Interpreter, args ...object.Object) object.Object {
+	err := checkArgs("replace", args, 3, object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ, object.INTEGER_OBJ)
+	if err != nil {
+		return err
+	}
+	s, old, replacement := stringArg(args, 0), stringArg(args, 1), stringArg(args, 2)
+	count := int64(strings.Count(s, old))
+	n := int64(-1)
+	if len(args) == 4 {
+		n = args[3].(*object.Integer).Value
+		if n >= 0 && n < count {
+			count = n
+		}
+	}
+	// count is at most len(s) + 1, the product can't overflow for
+	// strings that fit in memory
+	size := int64(len(s)) + count*(int64(len(replacement))-int64(len(old)))
+	if size > maxResultBytes {
+		return newError("replace: result would be longer than %d bytes", maxResultBytes)
+	}
+	if err := it.reserve(32 + size); err != nil {
+		return err
+	}
+	return &object.String{Value: strings.Replace(s, old, replacement, int(count))}
+}
+
+/*
+format(fmt, args...) replaces each {} in fmt with the next argument
+and {n} with argument n (counting from 0), {{ and }} stand for
+literal braces. Arguments are written the way print shows them.
+*/
+func builtinFormat(it *Interpreter, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, want at least 1")
+	}
+	fmtObj, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument 1 to `format` must be STRING, got %s", args[0].Type())
+	}
+	return format(it, fmtObj.Value, args[1:])
+}
+
+func builtinRepeat(it *Interpreter, args ...object.Object) object.Object {
+	if err := checkArgs("repeat", args, 2, object.STRING_OBJ, object.INTEGER_OBJ); err != nil {
+		return err
+	}
+	s, count := stringArg(args, 0), args[1].(*object.Integer).Value
+	if count < 0 {
+		return newError("repeat count must not be negative, got %d", count)
+	}
+	if s != "" && count > maxResultBytes/int64(len(s)) {
+		return newError("repeat: result would be longer than %d bytes", maxResultBytes)
+	}
+	if err := it.reserve(32 + count*int64(len(s))); err != nil {
+		return err
+	}
+	return &object.String{Value: strings.Repeat(s, int(count))}
+}
+
+// pad_left(s, width) pads with spaces up to width characters, an optional
+// third argument gives the padding character
+func builtinPadLeft(it *Interpreter, args ...object.Object) object.Object {
+	return pad(it, "pad_left", args, true)
+}
+
+func builtinPadRight(it *Interpreter, args ...object.Object) object.Object {
+	return pad(it, "pad_right", args, false)
+}
+
+func pad(it *Interpreter, name string, args []object.Object, left bool) object.Object {
+	if err := checkArgs(name, args, 2, object.STRING_OBJ, object.INTEGER_OBJ, object.STRING_OBJ); err != nil {
+		return err
+	}
+	fill := " "
+	if len(args) == 3 {
+		fill = stringArg(args, 2)
+		if _, ok := singleRune(fill); !ok {
+			return newError("padding for `%s` must be a single character, got %q", name, fill)
+		}
+	}
+
+	s := stringArg(args, 0)
+	width, length := args[1].(*object.Integer).Value, int64(utf8.RuneCountInString(s))
+	if width <= length {
+		return args[0]
+	}
+	missing := width - length
+	if missing > maxResultBytes/int64(len(fill)) {
+		return newError("%s: result would be longer than %d bytes", name, maxResultBytes)
+	}
+	if err := it.reserve(32 + int64(len(s)) + missing*int64(len(fill))); err != nil {
+		return err
+	}
+	padding := strings.Repeat(fill, int(missing))
+	if left {
+		return &object.String{Value: padding + s}
+	}
+	return &object.String{Value: s + padding}
+}
+
+func format(it *Interpreter, template string, args []object.Object) object.Object {
+	var out strings.Builder
+	next := 0
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '{' && strings.HasPrefix(template[i:], "{{"):
+			out.WriteByte('{')
+			i++
+		case c == '}' && strings.HasPrefix(template[i:], "}}"):
+			out.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return newError("format: unclosed { in %q", template)
+			}
+			field := template[i+1 : i+end]
+			idx := next
+			if field == "" {
+				next++
+			} else {
+				n, err := strconv.Atoi(field)
+				if err != nil {
+					return newError("format: invalid field {%s}", field)
+				}
+				idx = n
+			}
+			if idx < 0 || idx >= len(args) {
+				return newError("format: no argument for field %d, got %d arguments", idx, len(args))
+			}
+			// the template is copied once, only the fields can make the
+			// result grow past it
+			value := args[idx].Inspect()
+			size := int64(out.Len()) + int64(len(value)) + int64(len(template)-i)
+			if size > maxResultBytes {
+				return newError("format: result would be longer than %d bytes", maxResultBytes)
+			}
+			if err := it.reserve(32 + size); err != nil {
+				return err
+			}
+			out.WriteString(value)
+			i += end
+		case c == '}':
+			return newError("format: single } in %q", template)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return &object.String{Value: out.String()}
+}
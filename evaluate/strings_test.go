@@ -0,0 +1,66 @@
+package evaluate
+
+import "testing"
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`split("a,b,,c", ",")`, []any{"a", "b", "", "c"}},
+		{"split(\"  one two\tthree \")", []any{"one", "two", "three"}},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join([1, 2.5, true])`, "12.500000true"},
+		{"trim(\"  hi \n\")", "hi"},
+		{`trim("xxhixx", "x")`, "hi"},
+		{`trim_left("  hi  ")`, "hi  "},
+		{`trim_right("  hi  ")`, "  hi"},
+		{`trim_right("hi!!", "!")`, "hi"},
+		{`upper("Hello")`, "HELLO"},
+		{`lower("Hello")`, "hello"},
+		{`contains("hello", "ell")`, true},
+		{`contains("hello", "xyz")`, false},
+		{`starts_with("hello", "he")`, true},
+		{`ends_with("hello", "he")`, false},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`replace("a-b-c", "-", "+", 1)`, "a+b-c"},
+		{`index_of("hello", "l")`, 2},
+		{`index_of("héllo", "l")`, 2},
+		{`index_of("hello", "z")`, -1},
+		{`repeat("ab", 3)`, "ababab"},
+		{`pad_left("7", 3, "0")`, "007"},
+		{`pad_right("ab", 4)`, "ab  "},
+		{`pad_left("long", 2)`, "long"},
+		{`chars("héy")`, []any{"h", "é", "y"}},
+		{`ord("A")`, 65},
+		{`chr(233)`, "é"},
+		{`format("{} + {} = {}", 1, 2, 1 + 2)`, "1 + 2 = 3"},
+		{`format("{1} before {0}", "a", "b")`, "b before a"},
+		{`format("{{}} {}", [1, 2])`, "{} [1, 2]"},
+
+		{`split(1)`, errorMessage("argument 1 to `split` must be STRING, got INTEGER")},
+		{`upper()`, errorMessage("wrong number of arguments. got=0, want=1")},
+		{`trim("a", "b", "c")`, errorMessage("wrong number of arguments. got=3, want=1 to 2")},
+		{`repeat("a", -1)`, errorMessage("repeat count must not be negative, got -1")},
+		{`repeat("ab", 4611686018427387904)`, errorMessage("repeat: result would be longer than 1073741824 bytes")},
+		{`repeat("", 4611686018427387904)`, ""},
+		{`pad_left("ab", -9223372036854775807 - 1)`, "ab"},
+		{`pad_left("a", 4611686018427387904)`, errorMessage("pad_left: result would be longer than 1073741824 bytes")},
+		{`pad_right("a", 9223372036854775807, "é")`, errorMessage("pad_right: result would be longer than 1073741824 bytes")},
+		{`join(fill(600000, repeat("a", 2000)))`, errorMessage("join: result would be longer than 1073741824 bytes")},
+		{`replace(repeat("a", 1000000), "a", repeat("b", 2000))`, errorMessage("replace: result would be longer than 1073741824 bytes")},
+		{`replace("a-b-c", "-", "", -5)`, "abc"},
+		{`ord("ab")`, errorMessage("argument to `ord` must be a single character, got \"ab\"")},
+		{`chr(-1)`, errorMessage("argument to `chr` is not a valid character code: -1")},
+		{`pad_left("a", 3, "xy")`, errorMessage("padding for `pad_left` must be a single character, got \"xy\"")},
+		{`format("{} {}", 1)`, errorMessage("format: no argument for field 1, got 1 arguments")},
+		{`format("{x}", 1)`, errorMessage("format: invalid field {x}")},
+		{`format("{", 1)`, errorMessage("format: unclosed { in \"{\"")},
+	}
+
+	for _, tt := range tests {
+		if !testObject(t, testEval(tt.input), tt.expected) {
+			t.Errorf("for input %s", tt.input)
+		}
+	}
+}
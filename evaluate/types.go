@@ -0,0 +1,128 @@
+package evaluate
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/JWSch4fer/interpreter/object"
+)
+
+// conversion between types and type introspection, a value that can't be
+// converted is an error object, never a panic
+var typeBuiltins = map[string]*object.Builtin{
+	/*
+		int(x) converts floats (dropping the fraction), strings and booleans
+		to an integer. int(s, base) parses s in base 2 to 36.
+	*/
+	"int": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("int", args, 1, anyArg, object.INTEGER_OBJ); err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				s, ok := args[0].(*object.String)
+				if !ok {
+					return newError("int with a base needs a STRING, got %s", args[0].Type())
+				}
+				return parseInt(s.Value, int(args[1].(*object.Integer).Value))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				f := math.Trunc(float64(arg.Value))
+				if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+					return newError("cannot convert %s to INTEGER", arg.Inspect())
+				}
+				return newInteger(int64(f))
+			case *object.String:
+				return parseInt(arg.Value, 10)
+			case *object.Boolean:
+				if arg.Value {
+					return newInteger(1)
+				}
+				return newInteger(0)
+			}
+			return newError("cannot convert %s to INTEGER", args[0].Type())
+		},
+	},
+	"float": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("float", args, 1, anyArg); err != nil {
+				return err
+			}
+			switch arg := args[0].(type) {
+			case *object.Float:
+				return arg
+			case *object.Integer:
+				return &object.Float{Value: float32(arg.Value)}
+			case *object.String:
+				f, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 32)
+				if err != nil {
+					return newError("cannot convert %q to FLOAT", arg.Value)
+				}
+				return &object.Float{Value: float32(f)}
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Float{Value: 1}
+				}
+				return &object.Float{Value: 0}
+			}
+			return newError("cannot convert %s to FLOAT", args[0].Type())
+		},
+	},
+	// str(x) is x the way print shows it
+	"str": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("str", args, 1, anyArg); err != nil {
+				return err
+			}
+			if s, ok := args[0].(*object.String); ok {
+				return s
+			}
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+	// bool(x) follows if: only false and NULL are false
+	"bool": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("bool", args, 1, anyArg); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(isTruthy(args[0]))
+		},
+	},
+	"type": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("type", args, 1, anyArg); err != nil {
+				return err
+			}
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"is_null": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("is_null", args, 1, anyArg); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(args[0].Type() == object.NULL_OBJ)
+		},
+	},
+}
+
+func init() {
+	registerBuiltins(typeBuiltins)
+}
+
+func parseInt(s string, base int) object.Object {
+	if base < 2 || base > 36 {
+		return newError("int base must be between 2 and 36, got %d", base)
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(s), base, 64)
+	if err != nil {
+		return newError("cannot convert %q to INTEGER", s)
+	}
+	return newInteger(i)
+}
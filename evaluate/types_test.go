@@ -0,0 +1,59 @@
+package evaluate
+
+import "testing"
+
+func TestTypeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`int("42")`, 42},
+		{`int(" -7 ")`, -7},
+		{`int("ff", 16)`, 255},
+		{`int("101", 2)`, 5},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int(true)`, 1},
+		{`int(7)`, 7},
+		{`float("2.5")`, 2.5},
+		{`float(3)`, 3.0},
+		{`float(false)`, 0.0},
+		{`str(3) + "!"`, "3!"},
+		{`str(3)`, "3"},
+		{`"hello " + str(3.0)`, "hello 3.000000"},
+		{`str([1, "a"])`, "[1, a]"},
+		{`str("same")`, "same"},
+		{`bool(0)`, true},
+		{`bool(NULL)`, false},
+		{`bool(false)`, false},
+		{`bool("")`, true},
+		{`type(1)`, "INTEGER"},
+		{`type(1.5)`, "FLOAT"},
+		{`type("a")`, "STRING"},
+		{`type([])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(set())`, "SET"},
+		{`type(NULL)`, "NULL"},
+		{`type(df(x) { x })`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`is_null(NULL)`, true},
+		{`is_null([1][5])`, true},
+		{`is_null(0)`, false},
+
+		{`int("abc")`, errorMessage(`cannot convert "abc" to INTEGER`)},
+		{`int("")`, errorMessage(`cannot convert "" to INTEGER`)},
+		{`int("99999999999999999999")`, errorMessage(`cannot convert "99999999999999999999" to INTEGER`)},
+		{`int("12", 1)`, errorMessage("int base must be between 2 and 36, got 1")},
+		{`int(12, 16)`, errorMessage("int with a base needs a STRING, got INTEGER")},
+		{`int([1])`, errorMessage("cannot convert ARRAY to INTEGER")},
+		{`float("1.2.3")`, errorMessage(`cannot convert "1.2.3" to FLOAT`)},
+		{`float(NULL)`, errorMessage("cannot convert NULL to FLOAT")},
+		{`type()`, errorMessage("wrong number of arguments. got=0, want=1")},
+	}
+
+	for _, tt := range tests {
+		if !testObject(t, testEval(tt.input), tt.expected) {
+			t.Errorf("for input %s", tt.input)
+		}
+	}
+}
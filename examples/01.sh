@@ -35,33 +35,7 @@ let hmap = iter_arr(input, temp_arr, hmap, hmap_id, idx);
 // now sum all sub arrays to find answer AoC 2022 day 1!!!  //
 // ======================================================== //
 
-// define a sum function with builtin array functions//
-let reduce = df(arr, initial, f) {
-    let iter = df(arr, result) {
-        if (len(arr) == 0) {
-            result
-        } else {
-            iter(rest(arr), f(result, first(arr)));
-        }
-    };
-    iter(arr, initial);
-};
-
-let sum = df(arr) {
-    reduce(arr, 0, df(initial, el) { initial + el });
-};
-
-// iterate over the entire hash and find the key with the largest sum //
-let iter_hash = df(hash, max_sum, key) {
-    if (key == len(hash)) { max_sum }
-    else {
-        let x = sum(hash[key]);
-        if (x > max_sum){let max_sum = x;}
-        iter_hash(hash, max_sum, key + 1);
-    }
-}
-
-let max_sum = 0;
-let max_sum = iter_hash(hmap, max_sum, 0);
+// sum every group in the hash and keep the largest //
+let max_sum = max(map(sum, values(hmap)));
 print("Awesome we solved AoC day 1 from 2022!")
 print(max_sum);
@@ -214,3 +214,12 @@ func (l *Lexer) peekChar() byte {
 		return l.input[l.readPosition]
 	}
 }
+
+// Offset is how far into the input the lexer has read,
+// right after NextToken it is the end of the returned token
+func (l *Lexer) Offset() int {
+	if l.position > len(l.input) {
+		return len(l.input)
+	}
+	return l.position
+}
@@ -244,3 +244,23 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestOffset(t *testing.T) {
+	input := `let s = "hi"; // note // x1`
+	expected := []int{3, 5, 7, 12, 13, 24, 26, 27, 27}
+
+	l := New(input)
+	for i, end := range expected {
+		l.NextToken()
+		if l.Offset() != end {
+			t.Errorf("tokens[%d] - wrong offset: expected %d, got %d", i, end, l.Offset())
+		}
+	}
+
+	// an unterminated string runs to the end of the input
+	l = New(`"open`)
+	l.NextToken()
+	if l.Offset() != 5 {
+		t.Errorf("unterminated string - wrong offset: expected 5, got %d", l.Offset())
+	}
+}
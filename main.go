@@ -1,13 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
 
 	"github.com/JWSch4fer/interpreter/evaluate"
 	"github.com/JWSch4fer/interpreter/lexer"
-	"github.com/JWSch4fer/interpreter/object"
 	"github.com/JWSch4fer/interpreter/parser"
 	"github.com/JWSch4fer/interpreter/repl"
 )
@@ -19,9 +19,12 @@ func main() {
 		panic(err)
 	}
 
+	noColor := flag.Bool("no-color", false, "disable coloured REPL input and output")
+	flag.Parse()
+
 	// If a file path is provided as an argument, read and execute
-	if len(os.Args) > 1 {
-		filePath := os.Args[1]
+	if flag.NArg() > 0 {
+		filePath := flag.Arg(0)
 		content, err := os.ReadFile(filePath)
 		if err != nil {
 			fmt.Printf("Error reading file %s: %s\n", filePath, err)
@@ -41,9 +44,9 @@ func main() {
 			os.Exit(1)
 		}
 
-		//Create Environment and evaluate the program
-		env := object.NewEnvironment()
-		_ = evaluate.Eval(program, env)
+		//Create an interpreter and evaluate the program in its environment
+		it := evaluate.New()
+		_ = evaluate.Eval(program, it.Env())
 		/*
 			TODO: need to update this right now we just print the last thing
 				that was run by the program. So if the last ast node evaluated
@@ -58,6 +61,6 @@ func main() {
 
 		fmt.Printf("Hello %s\n", user.Username)
 		fmt.Println("starting interpreter...")
-		repl.Start(os.Stdin, os.Stdout)
+		repl.StartWithOptions(os.Stdin, os.Stdout, repl.Options{NoColor: *noColor})
 	}
 }
@@ -1,14 +1,21 @@
 package object
 
+import "sort"
+
 // Environment setup for storing/binding variables to data types
 type Environment struct {
 	store map[string]Object
 	outer *Environment
+
+	// whatever owns this environment (e.g. an embedding interpreter)
+	// enclosed environments share the host of the environment they extend
+	host any
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
+	env.host = outer.host
 	return env
 }
 
@@ -28,3 +35,23 @@ func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// attach an owner to the environment, set this before creating enclosed environments
+func (e *Environment) SetHost(host any) { e.host = host }
+func (e *Environment) Host() any        { return e.host }
+
+// Names lists every name visible from this environment, sorted
+func (e *Environment) Names() []string {
+	seen := make(map[string]bool)
+	for env := e; env != nil; env = env.outer {
+		for name := range env.store {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
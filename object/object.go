@@ -2,6 +2,7 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
 	"math"
@@ -23,6 +24,7 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	SET_OBJ          = "SET"
 )
 
 type ObjectType string
@@ -51,6 +53,32 @@ type Integer struct {
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+/*
+Integer objects are never mutated once they are built, so like TRUE/FALSE
+we can hand out the same object for every small integer instead of
+allocating a new one for every loop counter and index.
+*/
+const (
+	SmallIntMin = -128
+	SmallIntMax = 1024
+)
+
+var smallInts = func() []*Integer {
+	ints := make([]*Integer, SmallIntMax-SmallIntMin+1)
+	for i := range ints {
+		ints[i] = &Integer{Value: int64(i + SmallIntMin)}
+	}
+	return ints
+}()
+
+// NewInteger returns the shared object for small integers and allocates otherwise
+func NewInteger(value int64) *Integer {
+	if value >= SmallIntMin && value <= SmallIntMax {
+		return smallInts[value-SmallIntMin]
+	}
+	return &Integer{Value: value}
+}
+
 // build object representation of integers
 type Float struct {
 	Value float32
@@ -70,15 +98,53 @@ func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
 // build object representation of boolean
 type Array struct {
 	Elements []Object
+
+	// set by Append, nil for arrays built any other way
+	buffer  *arrayBuffer
+	inPlace bool
+}
+
+// backing array shared by arrays built with Append, used counts the
+// slots one of them has claimed
+type arrayBuffer struct {
+	used int
+}
+
+/*
+Append returns a new array with x added at the end and leaves a as it is.
+Arrays never change once built, so the result can share a's elements:
+the first array appended to at a given length writes into the spare
+capacity, any later one (or an array not built by Append) copies into a
+buffer twice the size. Appending in a loop is amortised O(1) like Go's
+append, and no two arrays ever see each other's elements.
+*/
+func (a *Array) Append(x Object) *Array {
+	n := len(a.Elements)
+	if a.buffer != nil && a.buffer.used == n && n < cap(a.Elements) {
+		a.buffer.used++
+		return &Array{Elements: append(a.Elements, x), buffer: a.buffer, inPlace: true}
+	}
+	elements := make([]Object, n+1, max(2*n, 4))
+	copy(elements, a.Elements)
+	elements[n] = x
+	return &Array{Elements: elements, buffer: &arrayBuffer{used: n + 1}}
 }
 
+// InPlace reports whether Append built a without copying, only the new
+// element then takes up memory of its own
+func (a *Array) InPlace() bool { return a.inPlace }
+
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
-func (a *Array) Inspect() string {
+func (a *Array) Inspect() string  { return a.inspect(map[Object]bool{}) }
+
+func (a *Array) inspect(visiting map[Object]bool) string {
+	visiting[a] = true
+	defer delete(visiting, a)
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspectNested(e, visiting))
 	}
 
 	out.WriteString("[")
@@ -173,10 +239,31 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+/*
+a float holding a whole number hashes like the integer with that value,
+so h[1] and h[1.0] are the same entry. Every NaN hashes the same and NaN
+keys are equal to each other so they can be looked up again.
+*/
 func (f *Float) HashKey() HashKey {
+	if v, ok := IntegralFloat(f.Value); ok {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(v)}
+	}
+	if math.IsNaN(float64(f.Value)) {
+		return HashKey{Type: f.Type(), Value: uint64(math.Float32bits(float32(math.NaN())))}
+	}
 	return HashKey{Type: f.Type(), Value: uint64(math.Float32bits(f.Value))}
 }
 
+// IntegralFloat returns the integer f holds when it is a whole number in
+// the range of an int64
+func IntegralFloat(f float32) (int64, bool) {
+	v := float64(f)
+	if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(v), true
+}
+
 func (s *String) HashKey() HashKey {
 	// return cached hash key
 	if s.cachedHashKey != nil {
@@ -191,22 +278,265 @@ func (s *String) HashKey() HashKey {
 	return key
 }
 
+// arrays hash their elements in order, AsHashable checks they all can be
+func (a *Array) HashKey() HashKey {
+	h := fnv.New64()
+	var buf [8]byte
+	for _, el := range a.Elements {
+		key := el.(Hashable).HashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf[:], key.Value)
+		h.Write(buf[:])
+	}
+	return HashKey{Type: a.Type(), Value: h.Sum64()}
+}
+
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+// AsHashable reports whether obj can be used as a hash key, arrays can
+// when all of their elements can, hashes when they are frozen and all of
+// their values can. A hash that contains itself can't.
+func AsHashable(obj Object) (Hashable, bool) {
+	return asHashable(obj, map[*Hash]bool{})
+}
+
+func asHashable(obj Object, visiting map[*Hash]bool) (Hashable, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		for _, el := range obj.Elements {
+			if _, ok := asHashable(el, visiting); !ok {
+				return nil, false
+			}
+		}
+		return obj, true
+	case *Hash:
+		if !obj.frozen || visiting[obj] {
+			return nil, false
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		for _, pair := range obj.Pairs() {
+			if _, ok := asHashable(pair.Key, visiting); !ok {
+				return nil, false
+			}
+			if _, ok := asHashable(pair.Value, visiting); !ok {
+				return nil, false
+			}
+		}
+		return obj, true
+	}
+	key, ok := obj.(Hashable)
+	return key, ok
+}
+
+// keys with the same HashKey are compared on their values so colliding
+// hashes never overwrite each other
+func keysEqual(a, b Object) bool {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *Float:
+			v, ok := IntegralFloat(b.Value)
+			return ok && v == a.Value
+		}
+		return false
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return keysEqual(b, a)
+		case *Float:
+			return a.Value == b.Value || math.IsNaN(float64(a.Value)) && math.IsNaN(float64(b.Value))
+		}
+		return false
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !keysEqual(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key.(Hashable))
+			if !ok || !keysEqual(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
 type HashPair struct {
 	Key   Object
 	Value Object
 }
 
+/*
+Hash keeps its pairs in insertion order so printing and iterating a hash
+gives the same result on every run. Lookups go through an index from
+HashKey to the entries with that HashKey, keys in the same bucket are
+told apart by comparing them. Replacing the value of a key keeps its
+position, deleting leaves a hole that is compacted once holes make up
+half the entries.
+
+A frozen hash can't be changed from the language anymore, the evaluator
+refuses to assign to it or delete from it. That makes it usable as a key.
+*/
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	entries []HashPair // nil Key marks a deleted entry
+	index   map[HashKey][]int
+	deleted int
+
+	frozen        bool
+	cachedHashKey *HashKey // frozen hashes can't change, so neither can their key
+}
+
+func NewHash() *Hash {
+	return &Hash{index: make(map[HashKey][]int)}
+}
+
+// position of key in entries, -1 when it isn't in the hash
+func (h *Hash) find(key Hashable, hashKey HashKey) int {
+	for _, idx := range h.index[hashKey] {
+		if keysEqual(h.entries[idx].Key, key) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	idx := h.find(key, key.HashKey())
+	if idx < 0 {
+		return HashPair{}, false
+	}
+	return h.entries[idx], true
+}
+
+func (h *Hash) Set(key Hashable, value Object) {
+	if h.index == nil {
+		h.index = make(map[HashKey][]int)
+	}
+	hashKey := key.HashKey()
+	if idx := h.find(key, hashKey); idx >= 0 {
+		h.entries[idx].Value = value
+		return
+	}
+	h.index[hashKey] = append(h.index[hashKey], len(h.entries))
+	h.entries = append(h.entries, HashPair{Key: key, Value: value})
+}
+
+// remove key from the hash, reports whether it was present
+func (h *Hash) Delete(key Hashable) bool {
+	hashKey := key.HashKey()
+	idx := h.find(key, hashKey)
+	if idx < 0 {
+		return false
+	}
+	bucket := h.index[hashKey]
+	for i, entry := range bucket {
+		if entry == idx {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(h.index, hashKey)
+	} else {
+		h.index[hashKey] = bucket
+	}
+	h.entries[idx] = HashPair{}
+	h.deleted++
+	if h.deleted > len(h.entries)/2 {
+		h.compact()
+	}
+	return true
+}
+
+func (h *Hash) compact() {
+	live := make([]HashPair, 0, h.Len())
+	index := make(map[HashKey][]int, len(h.index))
+	for _, pair := range h.entries {
+		if pair.Key != nil {
+			hashKey := pair.Key.(Hashable).HashKey()
+			index[hashKey] = append(index[hashKey], len(live))
+			live = append(live, pair)
+		}
+	}
+	h.entries, h.index, h.deleted = live, index, 0
+}
+
+func (h *Hash) Len() int { return len(h.entries) - h.deleted }
+
+func (h *Hash) Frozen() bool { return h.frozen }
+
+// the same pairs give the same key in any order, AsHashable checks that
+// the hash is frozen and every key and value can be hashed
+func (h *Hash) HashKey() HashKey {
+	if h.cachedHashKey != nil {
+		return *h.cachedHashKey
+	}
+
+	var sum uint64
+	var buf [8]byte
+	for _, pair := range h.Pairs() {
+		f := fnv.New64()
+		for _, obj := range []Object{pair.Key, pair.Value} {
+			key := obj.(Hashable).HashKey()
+			f.Write([]byte(key.Type))
+			binary.LittleEndian.PutUint64(buf[:], key.Value)
+			f.Write(buf[:])
+		}
+		sum += f.Sum64()
+	}
+	key := HashKey{Type: h.Type(), Value: sum}
+	h.cachedHashKey = &key
+
+	return key
+}
+
+// Pairs returns the pairs in insertion order
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, h.Len())
+	for _, pair := range h.entries {
+		if pair.Key != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
+func (h *Hash) Inspect() string  { return h.inspect(map[Object]bool{}) }
+
+func (h *Hash) inspect(visiting map[Object]bool) string {
+	visiting[h] = true
+	defer delete(visiting, h)
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	for _, pair := range h.Pairs() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", inspectNested(pair.Key, visiting), inspectNested(pair.Value, visiting)))
 	}
 
 	out.WriteString("{")
@@ -216,6 +546,117 @@ func (h *Hash) Inspect() string {
 	return out.String()
 }
 
-type Hashable interface {
-	HashKey() HashKey
+// Freeze marks obj and every hash inside it as frozen. Arrays have no
+// flag, they never change anyway, and neither do other values. Cycles are
+// fine, a frozen hash is skipped.
+func Freeze(obj Object) {
+	freeze(obj, map[*Array]bool{})
+}
+
+// seen keeps an array shared many times from being walked each time
+func freeze(obj Object, seen map[*Array]bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		if seen[obj] {
+			return
+		}
+		seen[obj] = true
+		for _, el := range obj.Elements {
+			freeze(el, seen)
+		}
+	case *Hash:
+		if obj.frozen {
+			return
+		}
+		obj.frozen = true
+		for _, pair := range obj.Pairs() {
+			freeze(pair.Key, seen)
+			freeze(pair.Value, seen)
+		}
+	}
+}
+
+// IsFrozen reports whether obj can't change anymore: a hash once it is
+// frozen, an array when every hash inside it is and any other value always
+func IsFrozen(obj Object) bool {
+	return isFrozen(obj, map[*Array]bool{})
+}
+
+func isFrozen(obj Object, checked map[*Array]bool) bool {
+	switch obj := obj.(type) {
+	case *Array:
+		if checked[obj] {
+			return true
+		}
+		checked[obj] = true
+		for _, el := range obj.Elements {
+			if !isFrozen(el, checked) {
+				return false
+			}
+		}
+	case *Hash:
+		return obj.frozen
+	}
+	return true
+}
+
+// arrays and hashes print their elements through inspectNested, so a hash
+// that holds itself shows up as {...} instead of recursing forever
+func inspectNested(obj Object, visiting map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		if visiting[obj] {
+			return "[...]"
+		}
+		return obj.inspect(visiting)
+	case *Hash:
+		if visiting[obj] {
+			return "{...}"
+		}
+		return obj.inspect(visiting)
+	}
+	return obj.Inspect()
+}
+
+/*
+Set holds distinct hashable values in insertion order. It is stored as a
+Hash from each element to itself, so membership follows the same rules as
+hash keys (1 and 1.0 are the same element, colliding hashes are compared).
+*/
+type Set struct {
+	members *Hash
+}
+
+func NewSet() *Set {
+	return &Set{members: NewHash()}
+}
+
+func (s *Set) Add(el Hashable) {
+	s.members.Set(el, el)
+}
+
+func (s *Set) Has(el Hashable) bool {
+	_, ok := s.members.Get(el)
+	return ok
+}
+
+func (s *Set) Len() int { return s.members.Len() }
+
+// Elements returns the elements in insertion order
+func (s *Set) Elements() []Object {
+	pairs := s.members.Pairs()
+	elements := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Key
+	}
+	return elements
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+func (s *Set) Inspect() string {
+	elements := []string{}
+	for _, el := range s.Elements() {
+		elements = append(elements, el.Inspect())
+	}
+	return "set([" + strings.Join(elements, ", ") + "])"
 }
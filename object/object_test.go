@@ -1,6 +1,9 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +20,188 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestHashInsertionOrder(t *testing.T) {
+	hash := NewHash()
+	for i, key := range []string{"c", "a", "b", "d"} {
+		hash.Set(&String{Value: key}, &Integer{Value: int64(i)})
+	}
+	// replacing a value keeps its place, re-adding a deleted key moves it to the end
+	hash.Set(&String{Value: "a"}, &Integer{Value: 10})
+	hash.Delete(&String{Value: "c"})
+	hash.Set(&String{Value: "c"}, &Integer{Value: 20})
+
+	expected := "{a: 10, b: 2, d: 3, c: 20}"
+	if hash.Inspect() != expected {
+		t.Errorf("wrong order. expected=%s, got=%s", expected, hash.Inspect())
+	}
+	if hash.Len() != 4 {
+		t.Errorf("wrong length. got=%d", hash.Len())
+	}
+	pair, ok := hash.Get(&String{Value: "d"})
+	if !ok || pair.Value.Inspect() != "3" {
+		t.Errorf("lookup of d failed. got=%v, %v", pair.Value, ok)
+	}
+}
+
+func TestHashDeleteCompacts(t *testing.T) {
+	hash := NewHash()
+	for i := 0; i < 10; i++ {
+		hash.Set(&Integer{Value: int64(i)}, &Integer{Value: int64(i)})
+	}
+	for i := 0; i < 8; i++ {
+		if !hash.Delete(&Integer{Value: int64(i)}) {
+			t.Fatalf("delete of %d reported missing key", i)
+		}
+	}
+	if hash.Delete(&Integer{Value: 0}) {
+		t.Errorf("deleting a missing key reported success")
+	}
+	if len(hash.entries) >= 10 {
+		t.Errorf("deleted entries were not compacted. len=%d", len(hash.entries))
+	}
+	if hash.Inspect() != "{8: 8, 9: 9}" {
+		t.Errorf("wrong pairs after delete. got=%s", hash.Inspect())
+	}
+	for _, key := range []int64{8, 9} {
+		if pair, ok := hash.Get(&Integer{Value: key}); !ok || pair.Value.(*Integer).Value != key {
+			t.Errorf("lookup of %d failed after compaction", key)
+		}
+	}
+}
+
+func TestHashCollisions(t *testing.T) {
+	// two different strings forced onto the same hash key
+	collision := HashKey{Type: STRING_OBJ, Value: 42}
+	a := &String{Value: "a", cachedHashKey: &collision}
+	b := &String{Value: "b", cachedHashKey: &collision}
+
+	hash := NewHash()
+	hash.Set(a, &Integer{Value: 1})
+	hash.Set(b, &Integer{Value: 2})
+	if hash.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other. got=%s", hash.Inspect())
+	}
+	for key, want := range map[*String]int64{a: 1, b: 2} {
+		pair, ok := hash.Get(key)
+		if !ok || pair.Value.(*Integer).Value != want {
+			t.Errorf("lookup of %s wrong. got=%v, %v", key.Value, pair.Value, ok)
+		}
+	}
+
+	hash.Delete(a)
+	if _, ok := hash.Get(a); ok {
+		t.Errorf("deleted key is still present")
+	}
+	if pair, ok := hash.Get(b); !ok || pair.Value.(*Integer).Value != 2 {
+		t.Errorf("deleting a colliding key removed the other one")
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	point := func(x, y int64) *Array {
+		return &Array{Elements: []Object{&Integer{Value: x}, &Integer{Value: y}}}
+	}
+	if point(1, 2).HashKey() != point(1, 2).HashKey() {
+		t.Errorf("arrays with same elements have different hash keys")
+	}
+	if point(1, 2).HashKey() == point(2, 1).HashKey() {
+		t.Errorf("arrays with different elements have same hash keys")
+	}
+
+	nested := &Array{Elements: []Object{point(1, 2), &String{Value: "x"}}}
+	if _, ok := AsHashable(nested); !ok {
+		t.Errorf("array of hashable values is not hashable")
+	}
+	withHash := &Array{Elements: []Object{NewHash()}}
+	if _, ok := AsHashable(withHash); ok {
+		t.Errorf("array holding a hash should not be hashable")
+	}
+}
+
+func TestArrayAppend(t *testing.T) {
+	base := (&Array{}).Append(&Integer{Value: 1})
+	grown := base.Append(&Integer{Value: 2})
+	if !grown.InPlace() {
+		t.Errorf("appending to the newest array should reuse its buffer")
+	}
+
+	// a second append at the same length must not overwrite grown
+	other := base.Append(&Integer{Value: 3})
+	if other.InPlace() {
+		t.Errorf("appending twice at the same length should copy")
+	}
+	if grown.Inspect() != "[1, 2]" || other.Inspect() != "[1, 3]" || base.Inspect() != "[1]" {
+		t.Errorf("arrays share elements: base=%s grown=%s other=%s", base.Inspect(), grown.Inspect(), other.Inspect())
+	}
+
+	// arrays not built by Append may share their backing array elsewhere
+	backing := []Object{&Integer{Value: 1}, &Integer{Value: 2}}
+	prefix := &Array{Elements: backing[:1]}
+	prefix.Append(&Integer{Value: 9})
+	if backing[1].(*Integer).Value != 2 {
+		t.Errorf("Append wrote into a slice it doesn't own")
+	}
+}
+
+func TestFrozenHashKey(t *testing.T) {
+	build := func(keys ...string) *Hash {
+		h := NewHash()
+		for i, k := range keys {
+			h.Set(&String{Value: k}, &Integer{Value: int64(i % 2)})
+		}
+		return h
+	}
+
+	h := build("a", "b")
+	if _, ok := AsHashable(h); ok {
+		t.Errorf("a hash that isn't frozen should not be hashable")
+	}
+	Freeze(h)
+	key, ok := AsHashable(h)
+	if !ok {
+		t.Fatalf("a frozen hash of hashable values should be hashable")
+	}
+
+	// same pairs in another order are the same key
+	other := NewHash()
+	other.Set(&String{Value: "b"}, &Integer{Value: 1})
+	other.Set(&String{Value: "a"}, &Integer{Value: 0})
+	Freeze(other)
+	if key.HashKey() != other.HashKey() || !keysEqual(h, other) {
+		t.Errorf("frozen hashes with the same pairs are different keys")
+	}
+	different := build("a", "c")
+	Freeze(different)
+	if keysEqual(h, different) {
+		t.Errorf("frozen hashes with different pairs are the same key")
+	}
+}
+
+func TestNumericHashKeys(t *testing.T) {
+	tests := []struct {
+		key   Hashable
+		other Hashable
+		same  bool
+	}{
+		{&Integer{Value: 1}, &Float{Value: 1.0}, true},
+		{&Integer{Value: -3}, &Float{Value: -3.0}, true},
+		{&Integer{Value: 0}, &Float{Value: float32(math.Copysign(0, -1))}, true},
+		{&Integer{Value: 1}, &Float{Value: 1.5}, false},
+		{&Integer{Value: 16777217}, &Float{Value: 16777216}, false},
+		{&Float{Value: float32(math.NaN())}, &Float{Value: float32(math.NaN())}, true},
+		{&Integer{Value: 1}, &String{Value: "1"}, false},
+	}
+	for _, tt := range tests {
+		hash := NewHash()
+		hash.Set(tt.key, &Boolean{Value: true})
+		_, found := hash.Get(tt.other)
+		if found != tt.same {
+			t.Errorf("lookup of %s in hash keyed by %s. expected found=%t, got=%t",
+				tt.other.Inspect(), tt.key.Inspect(), tt.same, found)
+		}
+		if tt.same && tt.key.HashKey() != tt.other.HashKey() {
+			t.Errorf("equal keys %s and %s have different hash keys", tt.key.Inspect(), tt.other.Inspect())
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/JWSch4fer/interpreter/ast"
 	"github.com/JWSch4fer/interpreter/lexer"
+	"github.com/JWSch4fer/interpreter/object"
 	"github.com/JWSch4fer/interpreter/token"
 )
 
@@ -26,6 +27,7 @@ var precedences = map[token.TokenType]int{
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
 	token.GT:       LESSGREATER,
+	token.IN:       LESSGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
@@ -88,6 +90,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 
 	//function calls
@@ -100,18 +103,51 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
+// a[index] or a slice like a[start:end:step] where every bound is optional
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.currToken, Left: left}
 
 	p.NextToken()
+	if p.currTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		p.NextToken()
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return exp
+}
+
+// called with the first colon as the current token
+func (p *Parser) parseSliceExpression(tok token.Token, left, start ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: tok, Left: left, Start: start}
+
+	exp.End = p.parseSliceBound()
+	if p.peekTokenIs(token.COLON) {
+		p.NextToken()
+		exp.Step = p.parseSliceBound()
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 	return exp
 }
 
+// the bound after the current colon, nil when it is left out
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekTokenIs(token.COLON) || p.peekTokenIs(token.RBRACKET) {
+		return nil
+	}
+	p.NextToken()
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.currToken}
 	hash.Pairs = make(map[ast.Expression]ast.Expression)
@@ -128,6 +164,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		value := p.parseExpression(LOWEST)
 
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -504,8 +541,16 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	return leftExp
 }
 
+/*
+literals always evaluate to the same value, so the parser builds their
+object once and the evaluator only reads it. Nothing is written to the
+tree during evaluation, so one parsed program can be evaluated from
+several goroutines at once.
+*/
 func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
+	obj := &object.String{Value: p.currToken.Literal}
+	obj.HashKey() // the hash key is cached on first use, fill it in now
+	return &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal, Cached: obj}
 }
 
 func (p *Parser) parseNumberLiteral() ast.Expression {
@@ -521,6 +566,7 @@ func (p *Parser) parseNumberLiteral() ast.Expression {
 			return nil
 		}
 		floatLit.Value = float32(value)
+		floatLit.Cached = &object.Float{Value: floatLit.Value}
 		return floatLit
 	}
 
@@ -532,6 +578,7 @@ func (p *Parser) parseNumberLiteral() ast.Expression {
 		return nil
 	}
 	lit.Value = value
+	lit.Cached = object.NewInteger(value)
 	return lit
 }
 
@@ -396,6 +396,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * [1, 2, 3, 4][b * c] * d",
 			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
 		},
+		{
+			"a[1:2]",
+			"(a[1:2])",
+		},
+		{
+			"a[:-1]",
+			"(a[:(-1)])",
+		},
+		{
+			"a[::b + 1]",
+			"(a[::(b + 1)])",
+		},
+		{
+			"a[1:][0]",
+			"((a[1:])[0])",
+		},
+		{
+			"a + 1 in b == true",
+			"(((a + 1) in b) == true)",
+		},
+		{
+			"!(x in s)",
+			"(!(x in s))",
+		},
 		{
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
@@ -888,6 +912,14 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		expectedValue := expected[literal.String()]
 		testIntegerLiteral(t, value, expectedValue)
 	}
+	for i, want := range []string{"one", "two", "three"} {
+		if got := hash.Keys[i].String(); got != want {
+			t.Errorf("hash.Keys[%d] wrong. expected=%q, got=%q", i, want, got)
+		}
+	}
+	if hash.String() != "{one:1, two:2, three:3}" {
+		t.Errorf("hash.String() wrong. got=%q", hash.String())
+	}
 }
 
 func TestParsingEmptyHashLiteral(t *testing.T) {
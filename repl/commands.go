@@ -0,0 +1,143 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JWSch4fer/interpreter/lexer"
+	"github.com/JWSch4fer/interpreter/parser"
+	"github.com/JWSch4fer/interpreter/token"
+)
+
+// lines starting with this are REPL commands instead of code
+const COMMAND_PREFIX = ":"
+
+type command struct {
+	args string // shown in :help
+	help string
+	run  func(s *session, arg string)
+}
+
+var commands map[string]command
+
+// filled in init because :help lists the commands map itself
+func init() {
+	commands = map[string]command{
+		"help":   {"", "show this help", (*session).cmdHelp},
+		"env":    {"", "list the bindings of the session with their types", (*session).cmdEnv},
+		"type":   {"expr", "evaluate expr and show the type of the result", (*session).cmdType},
+		"ast":    {"expr", "show how expr is parsed", (*session).cmdAst},
+		"tokens": {"expr", "show the tokens the lexer produces for expr", (*session).cmdTokens},
+		"load":   {"file", "run a script in the session", (*session).cmdLoad},
+		"save":   {"file", "write the input of the session to a script", (*session).cmdSave},
+		"reset":  {"", "forget every binding and start over", (*session).cmdReset},
+		"time":   {"expr", "evaluate expr and show how long it took", (*session).cmdTime},
+	}
+}
+
+func (s *session) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, COMMAND_PREFIX), " ")
+	arg = strings.TrimSpace(arg)
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "unknown command %s%s, try %shelp\n", COMMAND_PREFIX, name, COMMAND_PREFIX)
+		return
+	}
+	if cmd.args != "" && arg == "" {
+		fmt.Fprintf(s.out, "usage: %s%s %s\n", COMMAND_PREFIX, name, cmd.args)
+		return
+	}
+	cmd.run(s, arg)
+}
+
+func (s *session) cmdHelp(string) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := commands[name]
+		usage := strings.TrimSpace(COMMAND_PREFIX + name + " " + cmd.args)
+		fmt.Fprintf(s.out, "  %-14s %s\n", usage, cmd.help)
+	}
+}
+
+func (s *session) cmdEnv(string) {
+	env := s.it.Env()
+	for _, name := range env.Names() {
+		val, _ := env.Get(name)
+		fmt.Fprintf(s.out, "  %s : %s\n", name, val.Type())
+	}
+}
+
+func (s *session) cmdType(expr string) {
+	evaluated, ok := s.eval(expr)
+	if evaluated == nil {
+		return
+	}
+	if !ok {
+		fmt.Fprintln(s.out, s.printer.format(evaluated))
+		return
+	}
+	fmt.Fprintln(s.out, s.printer.paint(colorConst, string(evaluated.Type())))
+}
+
+func (s *session) cmdAst(expr string) {
+	p := parser.New(lexer.New(expr))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		s.printParserErrors(p.Errors())
+		return
+	}
+	output := program.String()
+	if s.printer.color {
+		output = highlight(output)
+	}
+	fmt.Fprintln(s.out, output)
+}
+
+func (s *session) cmdTokens(expr string) {
+	l := lexer.New(expr)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "  %-10s %q\n", tok.Type, tok.Literal)
+	}
+}
+
+func (s *session) cmdLoad(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(s.out, "error reading file %s: %s\n", path, err)
+		return
+	}
+	s.evalSource(string(content))
+}
+
+func (s *session) cmdSave(path string) {
+	script := strings.Join(s.sources, "\n")
+	if script != "" {
+		script += "\n"
+	}
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		fmt.Fprintf(s.out, "error writing file %s: %s\n", path, err)
+		return
+	}
+	fmt.Fprintf(s.out, "saved %d entries to %s\n", len(s.sources), path)
+}
+
+func (s *session) cmdReset(string) {
+	s.reset()
+	io.WriteString(s.out, "session reset\n")
+}
+
+func (s *session) cmdTime(expr string) {
+	start := time.Now()
+	s.evalSource(expr)
+	fmt.Fprintf(s.out, "time: %s\n", time.Since(start))
+}
@@ -0,0 +1,497 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// returned when the user presses Ctrl-C, the REPL drops the pending input
+var errInterrupted = errors.New("interrupted")
+
+// where the line editor keeps history between sessions (relative to the home directory)
+const HISTORY_FILE = ".interpreter_history"
+
+// oldest entries are dropped once the history grows past this
+const historyLimit = 1000
+
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainReader reads whole lines, used when the input isn't a terminal
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	io.WriteString(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+/*
+editor is a small line editor for terminals: cursor movement, history
+(Up/Down, persisted to a file), reverse search (Ctrl-R) and tab completion.
+The terminal is only in raw mode while a line is read, so scripts calling
+input() see a normal terminal.
+*/
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	fd       uintptr
+	raw      bool // switch the terminal to raw mode while reading
+	history  *history
+	complete func(word string) []string
+
+	// colours the line while it is edited, nil shows it as typed
+	highlight func(line string) string
+
+	// the line being edited
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+func newEditor(in *bufio.Reader, out io.Writer, fd uintptr, complete func(string) []string) *editor {
+	return &editor{
+		in:       in,
+		out:      out,
+		fd:       fd,
+		raw:      true,
+		history:  loadHistory(historyPath(), historyLimit),
+		complete: complete,
+	}
+}
+
+func ctrl(key rune) rune { return key & 0x1f }
+
+// keys that arrive as escape sequences
+const (
+	keyUnknown rune = -(iota + 1)
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+	keyWordLeft
+	keyWordRight
+)
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	if e.raw {
+		restore, err := makeRaw(e.fd)
+		if err == nil {
+			defer restore()
+		}
+	}
+
+	e.prompt, e.buf, e.pos = prompt, nil, 0
+	browsing := e.history.browse()
+	e.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if len(e.buf) != 0 {
+				return e.finish(), nil
+			}
+			return "", err
+		}
+		if r == 27 {
+			r = e.readEscape()
+		}
+
+		switch r {
+		case '\r', '\n':
+			return e.finish(), nil
+		case ctrl('C'):
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case ctrl('D'):
+			if len(e.buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+		case ctrl('A'), keyHome:
+			e.pos = 0
+		case ctrl('E'), keyEnd:
+			e.pos = len(e.buf)
+		case ctrl('B'), keyLeft:
+			if e.pos > 0 {
+				e.pos--
+			}
+		case ctrl('F'), keyRight:
+			if e.pos < len(e.buf) {
+				e.pos++
+			}
+		case keyWordLeft:
+			e.pos = e.wordStart()
+		case keyWordRight:
+			for e.pos < len(e.buf) && !isWordRune(e.buf[e.pos]) {
+				e.pos++
+			}
+			for e.pos < len(e.buf) && isWordRune(e.buf[e.pos]) {
+				e.pos++
+			}
+		case ctrl('P'), keyUp:
+			if line, ok := browsing.previous(string(e.buf)); ok {
+				e.setLine(line)
+			}
+		case ctrl('N'), keyDown:
+			if line, ok := browsing.next(); ok {
+				e.setLine(line)
+			}
+		case ctrl('K'):
+			e.buf = e.buf[:e.pos]
+		case ctrl('U'):
+			e.buf = e.buf[e.pos:]
+			e.pos = 0
+		case ctrl('W'):
+			start := e.wordStart()
+			e.buf = append(e.buf[:start], e.buf[e.pos:]...)
+			e.pos = start
+		case ctrl('L'):
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+		case ctrl('R'):
+			if e.reverseSearch() {
+				return e.finish(), nil
+			}
+		case '\t':
+			e.completeWord()
+		case 127, ctrl('H'):
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+		case keyDelete:
+			e.deleteAt(e.pos)
+		default:
+			if r >= 32 {
+				e.insert([]rune{r})
+			}
+		}
+		e.refresh()
+	}
+}
+
+// end the line, it goes into the history unless it is blank
+func (e *editor) finish() string {
+	io.WriteString(e.out, "\r\n")
+	line := string(e.buf)
+	e.history.add(line)
+	return line
+}
+
+// redraw the prompt and the line, then put the cursor back in place
+func (e *editor) refresh() {
+	line := string(e.buf)
+	if e.highlight != nil {
+		line = e.highlight(line)
+	}
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, line)
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func (e *editor) setLine(line string) {
+	e.buf = []rune(line)
+	e.pos = len(e.buf)
+}
+
+func (e *editor) insert(text []rune) {
+	tail := append(text, e.buf[e.pos:]...)
+	e.buf = append(e.buf[:e.pos], tail...)
+	e.pos += len(text)
+}
+
+func (e *editor) deleteAt(pos int) {
+	if pos < len(e.buf) {
+		e.buf = append(e.buf[:pos], e.buf[pos+1:]...)
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// start of the word left of the cursor
+func (e *editor) wordStart() int {
+	start := e.pos
+	for start > 0 && !isWordRune(e.buf[start-1]) {
+		start--
+	}
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	return start
+}
+
+// translate the rest of an escape sequence (after ESC) into a key
+func (e *editor) readEscape() rune {
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return keyUnknown
+	}
+	switch next {
+	case 'b':
+		return keyWordLeft
+	case 'f':
+		return keyWordRight
+	case '[', 'O':
+	default:
+		return keyUnknown
+	}
+
+	// CSI sequences are parameters followed by a final letter or ~
+	var params strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return keyUnknown
+		}
+		if r >= '0' && r <= '9' || r == ';' {
+			params.WriteRune(r)
+			continue
+		}
+		switch {
+		case r == 'A':
+			return keyUp
+		case r == 'B':
+			return keyDown
+		case r == 'C' && strings.HasSuffix(params.String(), ";5"):
+			return keyWordRight
+		case r == 'D' && strings.HasSuffix(params.String(), ";5"):
+			return keyWordLeft
+		case r == 'C':
+			return keyRight
+		case r == 'D':
+			return keyLeft
+		case r == 'H':
+			return keyHome
+		case r == 'F':
+			return keyEnd
+		case r == '~':
+			switch params.String() {
+			case "1", "7":
+				return keyHome
+			case "4", "8":
+				return keyEnd
+			case "3":
+				return keyDelete
+			}
+		}
+		return keyUnknown
+	}
+}
+
+/*
+reverseSearch runs an incremental search through the history, Ctrl-R
+again jumps to older matches. Enter accepts the match and submits it
+(returns true), Ctrl-G/Ctrl-C restore the line, any other key accepts
+the match for further editing.
+*/
+func (e *editor) reverseSearch() bool {
+	original := string(e.buf)
+	query := []rune{}
+	match, idx := "", len(e.history.entries)
+
+	search := func(from int) {
+		for i := from - 1; i >= 0; i-- {
+			if strings.Contains(e.history.entries[i], string(query)) {
+				match, idx = e.history.entries[i], i
+				return
+			}
+		}
+	}
+	draw := func() {
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), match)
+	}
+
+	draw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			e.setLine(original)
+			return false
+		}
+		switch {
+		case r == ctrl('R'):
+			search(idx)
+		case r == 127 || r == ctrl('H'):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match, idx = "", len(e.history.entries)
+				search(idx)
+			}
+		case r == ctrl('G') || r == ctrl('C'):
+			e.setLine(original)
+			return false
+		case r == '\r' || r == '\n':
+			e.setLine(match)
+			return true
+		case r == 27:
+			e.readEscape()
+			e.setLine(match)
+			return false
+		case r >= 32:
+			query = append(query, r)
+			match, idx = "", len(e.history.entries)
+			search(idx)
+		default:
+			e.setLine(match)
+			return false
+		}
+		draw()
+	}
+}
+
+// complete the word left of the cursor, list the options if it is ambiguous
+func (e *editor) completeWord() {
+	start := e.pos
+	for start > 0 && isWordRune(e.buf[start-1]) {
+		start--
+	}
+	word := string(e.buf[start:e.pos])
+	if word == "" || e.complete == nil {
+		return
+	}
+
+	candidates := e.complete(word)
+	if len(candidates) == 0 {
+		io.WriteString(e.out, "\a")
+		return
+	}
+	common := commonPrefix(candidates)
+	if len(common) > len(word) {
+		e.insert([]rune(common[len(word):]))
+		return
+	}
+	if len(candidates) > 1 {
+		io.WriteString(e.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// history of entered lines, appended to a file as they are entered
+type history struct {
+	entries []string
+	path    string
+	limit   int
+}
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// a missing or unreadable file just means an empty history
+func loadHistory(path string, limit int) *history {
+	h := &history{path: path, limit: limit}
+	if path == "" {
+		return h
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > limit {
+		h.entries = h.entries[len(h.entries)-limit:]
+		h.rewrite()
+	}
+	return h
+}
+
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+		h.rewrite()
+		return
+	}
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
+
+func (h *history) rewrite() {
+	if h.path == "" {
+		return
+	}
+	os.WriteFile(h.path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+}
+
+// cursor for Up/Down navigation, remembers the line that was being typed
+type historyCursor struct {
+	h       *history
+	idx     int
+	pending string
+}
+
+func (h *history) browse() *historyCursor {
+	return &historyCursor{h: h, idx: len(h.entries)}
+}
+
+func (c *historyCursor) previous(current string) (string, bool) {
+	if c.idx == 0 {
+		return "", false
+	}
+	if c.idx == len(c.h.entries) {
+		c.pending = current
+	}
+	c.idx--
+	return c.h.entries[c.idx], true
+}
+
+func (c *historyCursor) next() (string, bool) {
+	if c.idx >= len(c.h.entries) {
+		return "", false
+	}
+	c.idx++
+	if c.idx == len(c.h.entries) {
+		return c.pending, true
+	}
+	return c.h.entries[c.idx], true
+}
@@ -0,0 +1,153 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// editor reading scripted key presses, without touching a real terminal
+func testEditor(t *testing.T, keys string, historyFile string, complete func(string) []string) (*editor, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	e := &editor{
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      &out,
+		history:  loadHistory(historyFile, historyLimit),
+		complete: complete,
+	}
+	return e, &out
+}
+
+func TestEditorEditing(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"let x = 5;\r", "let x = 5;"},
+		{"5 + 5\x7f6\r", "5 + 6"},
+		{"wrld\x1b[D\x1b[D\x1b[Do\r", "world"},
+		{"orld\x01w\r", "world"},
+		{"hello world\x1b[1;5D\x0b\r", "hello "},
+		{"hello world\x17\r", "hello "},
+		{"abc\x01\x1b[3~\r", "bc"},
+		{"abc\x15xyz\r", "xyz"},
+		{"ab\x02\x02\x05c\r", "abc"},
+	}
+
+	for _, tt := range tests {
+		e, _ := testEditor(t, tt.keys, "", nil)
+		line, err := e.ReadLine(PROMPT)
+		if err != nil {
+			t.Errorf("ReadLine(%q) returned error: %s", tt.keys, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("ReadLine(%q): got %q, want %q", tt.keys, line, tt.expected)
+		}
+	}
+}
+
+func TestEditorControlKeys(t *testing.T) {
+	e, _ := testEditor(t, "partial\x03", "", nil)
+	if _, err := e.ReadLine(PROMPT); err != errInterrupted {
+		t.Errorf("Ctrl-C: expected errInterrupted, got %v", err)
+	}
+
+	e, _ = testEditor(t, "\x04", "", nil)
+	if _, err := e.ReadLine(PROMPT); err != io.EOF {
+		t.Errorf("Ctrl-D on an empty line: expected EOF, got %v", err)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+	if err := os.WriteFile(path, []byte("let a = 1;\nlet b = 2;\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Up twice, Down once, then Up/Down back to the typed line
+	e, _ := testEditor(t, "\x1b[A\x1b[A\x1b[B\r"+"typed\x1b[A\x1b[B\r", path, nil)
+	line, _ := e.ReadLine(PROMPT)
+	if line != "let b = 2;" {
+		t.Errorf("history navigation: got %q", line)
+	}
+	line, _ = e.ReadLine(PROMPT)
+	if line != "typed" {
+		t.Errorf("returning from history lost the typed line: got %q", line)
+	}
+
+	// entered lines are persisted for the next session
+	reloaded := loadHistory(path, historyLimit)
+	expected := []string{"let a = 1;", "let b = 2;", "typed"}
+	if strings.Join(reloaded.entries, "|") != strings.Join(expected, "|") {
+		t.Errorf("history file: got %q, want %q", reloaded.entries, expected)
+	}
+
+	// the file is trimmed to the limit
+	limited := loadHistory(path, 2)
+	if len(limited.entries) != 2 || limited.entries[0] != "let b = 2;" {
+		t.Errorf("history limit not applied: got %q", limited.entries)
+	}
+}
+
+func TestEditorReverseSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+	if err := os.WriteFile(path, []byte("let add = df(x, y) { x + y };\nprint(1)\nlet adder = 5;\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"\x12add\r", "let adder = 5;"},
+		{"\x12add\x12\r", "let add = df(x, y) { x + y };"},
+		{"\x12pri\x05;\r", "print(1);"},
+		{"kept\x12zzz\x07\r", "kept"},
+	}
+	for _, tt := range tests {
+		e, _ := testEditor(t, tt.keys, path, nil)
+		e.history.path = ""
+		line, _ := e.ReadLine(PROMPT)
+		if line != tt.expected {
+			t.Errorf("reverse search %q: got %q, want %q", tt.keys, line, tt.expected)
+		}
+	}
+}
+
+func TestEditorCompletion(t *testing.T) {
+	complete := func(word string) []string {
+		matches := []string{}
+		for _, name := range []string{"len", "let", "last", "print", "push"} {
+			if strings.HasPrefix(name, word) {
+				matches = append(matches, name)
+			}
+		}
+		return matches
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"pri\t(1)\r", "print(1)"},
+		{"pu\t\r", "push"},
+		{"le\t\r", "le"},
+		{"x\t\r", "x"},
+	}
+	for _, tt := range tests {
+		e, out := testEditor(t, tt.keys, "", complete)
+		line, _ := e.ReadLine(PROMPT)
+		if line != tt.expected {
+			t.Errorf("completion %q: got %q, want %q", tt.keys, line, tt.expected)
+		}
+		if tt.keys == "le\t\r" && !strings.Contains(out.String(), "len  let") {
+			t.Errorf("ambiguous completion did not list candidates: %q", out.String())
+		}
+	}
+}
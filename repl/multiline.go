@@ -0,0 +1,71 @@
+package repl
+
+import (
+	"github.com/JWSch4fer/interpreter/lexer"
+	"github.com/JWSch4fer/interpreter/token"
+)
+
+// tokens that can't end a statement, more input has to follow them
+var continuationTokens = map[token.TokenType]bool{
+	token.ASSIGN:   true,
+	token.PLUS:     true,
+	token.MINUS:    true,
+	token.BANG:     true,
+	token.ASTERISK: true,
+	token.SLASH:    true,
+	token.LT:       true,
+	token.GT:       true,
+	token.EQ:       true,
+	token.NOT_EQ:   true,
+	token.IN:       true,
+	token.COMMA:    true,
+	token.COLON:    true,
+	token.LET:      true,
+	token.IF:       true,
+	token.ELSE:     true,
+	token.FUNCTION: true,
+}
+
+/*
+needsMoreInput reports whether source is an unfinished statement:
+an open string or comment, more opening than closing brackets, or a
+trailing operator. Extra closing brackets count as complete so the
+parser gets to report them.
+*/
+func needsMoreInput(source string) bool {
+	depth := 0
+	inString, inComment := false, false
+
+	for i := 0; i < len(source); i++ {
+		ch := source[i]
+		switch {
+		case inString:
+			if ch == '"' {
+				inString = false
+			}
+		case ch == '/' && i+1 < len(source) && source[i+1] == '/':
+			// comments are wrapped in a pair of //
+			inComment = !inComment
+			i++
+		case inComment:
+		case ch == '"':
+			inString = true
+		case ch == '(' || ch == '[' || ch == '{':
+			depth++
+		case ch == ')' || ch == ']' || ch == '}':
+			depth--
+		}
+	}
+	if inString || inComment || depth > 0 {
+		return true
+	}
+
+	last := token.Token{Type: token.EOF}
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type != token.COMMENT {
+			last = tok
+		}
+	}
+	return continuationTokens[last.Type]
+}
@@ -0,0 +1,188 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JWSch4fer/interpreter/lexer"
+	"github.com/JWSch4fer/interpreter/object"
+	"github.com/JWSch4fer/interpreter/token"
+)
+
+// ANSI colours used for input and values
+const (
+	colorReset   = "\x1b[0m"
+	colorKeyword = "\x1b[35m"
+	colorString  = "\x1b[32m"
+	colorNumber  = "\x1b[36m"
+	colorConst   = "\x1b[33m"
+	colorComment = "\x1b[90m"
+	colorError   = "\x1b[31m"
+	colorFunc    = "\x1b[34m"
+)
+
+func tokenColor(t token.TokenType) string {
+	switch t {
+	case token.FUNCTION, token.LET, token.IF, token.ELSE, token.RETURN, token.EXIT, token.IN:
+		return colorKeyword
+	case token.TRUE, token.FALSE, token.NULL:
+		return colorConst
+	case token.STRING:
+		return colorString
+	case token.INT, token.FLOAT:
+		return colorNumber
+	case token.COMMENT:
+		return colorComment
+	case token.ILLEGAL:
+		return colorError
+	}
+	return ""
+}
+
+/*
+highlight colours source using the lexer's tokens. Every token is
+coloured together with the whitespace in front of it, so the output
+has exactly the characters of the input plus escape codes.
+*/
+func highlight(source string) string {
+	var out strings.Builder
+	l := lexer.New(source)
+	start := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		end := l.Offset()
+		chunk := source[start:end]
+		start = end
+		if color := tokenColor(tok.Type); color != "" {
+			out.WriteString(color + chunk + colorReset)
+		} else {
+			out.WriteString(chunk)
+		}
+	}
+	out.WriteString(source[start:])
+	return out.String()
+}
+
+/*
+printer formats results for the REPL: collections that fit on a line stay
+inline, larger or nested ones are indented one element per line, and
+collections longer than maxItems are cut short. A hash can hold itself,
+a collection met again while it is being printed shows as {...} or [...].
+*/
+type printer struct {
+	color     bool
+	maxItems  int
+	lineWidth int
+	printing  map[object.Object]bool
+}
+
+const indentUnit = "  "
+
+func newPrinter(color bool) *printer {
+	return &printer{color: color, maxItems: 100, lineWidth: 72, printing: make(map[object.Object]bool)}
+}
+
+func (p *printer) paint(color, text string) string {
+	if !p.color || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// top level strings print as they are, like print() does
+func (p *printer) format(obj object.Object) string {
+	if str, ok := obj.(*object.String); ok {
+		return str.Value
+	}
+	return p.value(obj, "")
+}
+
+func (p *printer) value(obj object.Object, indent string) string {
+	switch obj := obj.(type) {
+	case *object.Integer, *object.Float:
+		return p.paint(colorNumber, obj.Inspect())
+	case *object.String:
+		return p.paint(colorString, fmt.Sprintf("%q", obj.Value))
+	case *object.Boolean, *object.Null:
+		return p.paint(colorConst, obj.Inspect())
+	case *object.Error:
+		return p.paint(colorError, obj.Inspect())
+	case *object.Function, *object.Builtin:
+		return p.paint(colorFunc, obj.Inspect())
+	case *object.Array:
+		if p.printing[obj] {
+			return "[...]"
+		}
+		p.printing[obj] = true
+		defer delete(p.printing, obj)
+		items := make([]string, 0, len(obj.Elements))
+		for i, el := range obj.Elements {
+			if i == p.maxItems {
+				break
+			}
+			items = append(items, p.value(el, indent+indentUnit))
+		}
+		return p.collection("[", "]", items, len(obj.Elements), indent)
+	case *object.Hash:
+		if p.printing[obj] {
+			return "{...}"
+		}
+		p.printing[obj] = true
+		defer delete(p.printing, obj)
+		pairs := obj.Pairs()
+		items := make([]string, 0, len(pairs))
+		for i, pair := range pairs {
+			if i == p.maxItems {
+				break
+			}
+			items = append(items, p.value(pair.Key, indent+indentUnit)+": "+p.value(pair.Value, indent+indentUnit))
+		}
+		return p.collection("{", "}", items, len(pairs), indent)
+	case *object.Set:
+		elements := obj.Elements()
+		items := make([]string, 0, len(elements))
+		for i, el := range elements {
+			if i == p.maxItems {
+				break
+			}
+			items = append(items, p.value(el, indent+indentUnit))
+		}
+		return p.collection("set([", "])", items, len(elements), indent)
+	default:
+		return obj.Inspect()
+	}
+}
+
+func (p *printer) collection(open, close string, items []string, total int, indent string) string {
+	if total > len(items) {
+		items = append(items, p.paint(colorComment, fmt.Sprintf("... %d more", total-len(items))))
+	}
+
+	inline := open + strings.Join(items, ", ") + close
+	if !strings.Contains(inline, "\n") && visibleLength(inline)+len(indent) <= p.lineWidth {
+		return inline
+	}
+
+	var out strings.Builder
+	out.WriteString(open + "\n")
+	for _, item := range items {
+		out.WriteString(indent + indentUnit + item + ",\n")
+	}
+	out.WriteString(indent + close)
+	return out.String()
+}
+
+// length of text as shown on screen, without escape codes
+func visibleLength(text string) int {
+	length, escaped := 0, false
+	for _, r := range text {
+		switch {
+		case r == '\x1b':
+			escaped = true
+		case escaped:
+			escaped = r != 'm'
+		default:
+			length++
+		}
+	}
+	return length
+}
@@ -0,0 +1,119 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JWSch4fer/interpreter/evaluate"
+)
+
+func TestHighlight(t *testing.T) {
+	input := `let s = "hi"; // note // if (true) { 1.5 }`
+	got := highlight(input)
+
+	if visibleLength(got) != len(input) {
+		t.Errorf("highlighting changed the visible text: %q", got)
+	}
+	for _, expected := range []string{
+		colorKeyword + "let" + colorReset,
+		colorString + ` "hi"` + colorReset,
+		colorComment + " // note //" + colorReset,
+		colorConst + "true" + colorReset,
+		colorNumber + " 1.5" + colorReset,
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("highlighted output does not contain %q: %q", expected, got)
+		}
+	}
+
+	// unfinished input is highlighted as far as it goes
+	if got := highlight(`print("open`); visibleLength(got) != len(`print("open`) {
+		t.Errorf("unterminated string lost text: %q", got)
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"top level"`, "top level"},
+		{`[1, "a", true, NULL]`, `[1, "a", true, null]`},
+		{`{"b": 2, "a": 1}`, `{"b": 2, "a": 1}`},
+		{`set(["x", 1, "x"])`, `set(["x", 1])`},
+		{
+			`[{"name": "a long name for a person", "age": 28}, {"name": "another long name", "age": 35}]`,
+			`[
+  {"name": "a long name for a person", "age": 28},
+  {"name": "another long name", "age": 35},
+]`,
+		},
+		{
+			`{"grid": [[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15], [16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27]]}`,
+			`{
+  "grid": [
+    [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15],
+    [16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27],
+  ],
+}`,
+		},
+	}
+
+	p := newPrinter(false)
+	for _, tt := range tests {
+		result, err := evaluate.New().Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+		if got := p.format(result); got != tt.expected {
+			t.Errorf("format(%s):\nwant %s\ngot  %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestPrinterCycles(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = {}; h["self"] = h; h`, `{"self": {...}}`},
+		{`let h = {}; let a = [h, 1]; h["a"] = a; a`, `[{"a": [...]}, 1]`},
+		// the same hash twice is not a cycle
+		{`let h = {"x": 1}; [h, h]`, `[{"x": 1}, {"x": 1}]`},
+	}
+
+	p := newPrinter(false)
+	for _, tt := range tests {
+		result, err := evaluate.New().Eval(tt.input)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.input, err)
+		}
+		if got := p.format(result); got != tt.expected {
+			t.Errorf("format(%s):\nwant %s\ngot  %s", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestPrinterTruncation(t *testing.T) {
+	p := newPrinter(false)
+	p.maxItems = 3
+	result, err := evaluate.New().Eval("[1, 2, 3, 4, 5]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.format(result); got != "[1, 2, 3, ... 2 more]" {
+		t.Errorf("wrong truncation: got %q", got)
+	}
+}
+
+func TestPrinterColor(t *testing.T) {
+	result, err := evaluate.New().Eval(`[1, "a"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := newPrinter(true).format(result)
+	expected := "[" + colorNumber + "1" + colorReset + ", " + colorString + `"a"` + colorReset + "]"
+	if got != expected {
+		t.Errorf("wrong colours: got %q, want %q", got, expected)
+	}
+}
@@ -2,55 +2,181 @@ package repl
 
 import (
 	"bufio"
-	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/JWSch4fer/interpreter/evaluate"
 	"github.com/JWSch4fer/interpreter/lexer"
 	"github.com/JWSch4fer/interpreter/object"
 	"github.com/JWSch4fer/interpreter/parser"
+	"github.com/JWSch4fer/interpreter/token"
 )
 
 const PROMPT = ">>"
 
+// shown while a statement spans several lines
+const CONTINUE_PROMPT = ".."
+
 const ERRORSEP = "\x1b[38;5;208m" + `
 ====================================================================
 ` + "\x1b[0m"
 
+// ERRORSEP without colour
+const PLAIN_ERRORSEP = `
+====================================================================
+`
+
+type Options struct {
+	// never colour input or output, colour is also off when out isn't a
+	// terminal or the NO_COLOR environment variable is set
+	NoColor bool
+}
+
+// state of one REPL session, :reset swaps the interpreter
+type session struct {
+	it      *evaluate.Interpreter
+	in      *bufio.Reader
+	out     io.Writer
+	printer *printer
+
+	// input that ran without errors, written out by :save
+	sources []string
+}
+
+func newSession(in *bufio.Reader, out io.Writer, color bool) *session {
+	s := &session{in: in, out: out, printer: newPrinter(color)}
+	s.reset()
+	return s
+}
+
+func (s *session) reset() {
+	// scripts calling input() share the reader with the prompt loop
+	s.it = evaluate.New()
+	s.it.SetStreams(s.in, s.out, s.out)
+	s.sources = nil
+}
+
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	StartWithOptions(in, out, Options{})
+}
+
+func StartWithOptions(in io.Reader, out io.Writer, opts Options) {
+	reader := bufio.NewReader(in)
+	s := newSession(reader, out, useColor(out, opts))
+
+	var lines lineReader = &plainReader{in: reader, out: out}
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		e := newEditor(reader, out, f.Fd(), s.complete)
+		if s.printer.color {
+			e.highlight = highlight
+		}
+		lines = e
+	}
+
+	// lines are collected until they form a complete statement
+	var buffer strings.Builder
 	for {
-		fmt.Printf(PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		prompt := PROMPT
+		if buffer.Len() != 0 {
+			prompt = CONTINUE_PROMPT
+		}
+		line, err := lines.ReadLine(prompt)
+		if err == errInterrupted {
+			buffer.Reset()
+			continue
+		}
+		if err != nil {
+			// run what is left so unfinished input still reports its errors
+			if buffer.Len() != 0 {
+				io.WriteString(out, "\n")
+				s.evalSource(buffer.String())
+			}
 			return
 		}
 
-		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
+		if buffer.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), COMMAND_PREFIX) {
+			s.runCommand(strings.TrimSpace(line))
+			continue
+		}
 
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+		buffer.WriteString(line)
+		buffer.WriteString("\n")
+		if needsMoreInput(buffer.String()) {
 			continue
 		}
 
-		evaluated := evaluate.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+		source := buffer.String()
+		buffer.Reset()
+		s.evalSource(source)
+	}
+}
+
+func useColor(out io.Writer, opts Options) bool {
+	if opts.NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	return ok && isTerminal(f.Fd())
+}
+
+// tab completion offers keywords, builtins and names bound in the REPL
+func (s *session) complete(word string) []string {
+	seen := make(map[string]bool)
+	matches := []string{}
+	for _, names := range [][]string{token.Keywords(), s.it.BuiltinNames(), s.it.Env().Names()} {
+		for _, name := range names {
+			if strings.HasPrefix(name, word) && !seen[name] {
+				seen[name] = true
+				matches = append(matches, name)
+			}
 		}
 	}
+	sort.Strings(matches)
+	return matches
+}
+
+// parse and evaluate source, print the result and remember it for :save
+func (s *session) evalSource(source string) {
+	evaluated, ok := s.eval(source)
+	if evaluated != nil {
+		io.WriteString(s.out, s.printer.format(evaluated))
+		io.WriteString(s.out, "\n")
+	}
+	if ok {
+		s.sources = append(s.sources, strings.TrimRight(source, "\n"))
+	}
+}
+
+// ok is false for parser errors (already printed) and runtime errors
+func (s *session) eval(source string) (object.Object, bool) {
+	l := lexer.New(source)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		s.printParserErrors(p.Errors())
+		return nil, false
+	}
+
+	evaluated := evaluate.Eval(program, s.it.Env())
+	if evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
+		return evaluated, false
+	}
+	return evaluated, true
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	io.WriteString(out, ERRORSEP)
-	io.WriteString(out, "Something Has Interrupted Internal Execution; Error Thrown.\n")
-	io.WriteString(out, "Check Syntax...\n")
+func (s *session) printParserErrors(errors []string) {
+	separator := PLAIN_ERRORSEP
+	if s.printer.color {
+		separator = ERRORSEP
+	}
+	io.WriteString(s.out, separator)
+	io.WriteString(s.out, "Something Has Interrupted Internal Execution; Error Thrown.\n")
+	io.WriteString(s.out, "Check Syntax...\n")
 	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+		io.WriteString(s.out, "\t"+msg+"\n")
 	}
-	io.WriteString(out, ERRORSEP)
+	io.WriteString(s.out, separator)
 }
@@ -0,0 +1,166 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JWSch4fer/interpreter/evaluate"
+)
+
+func TestStartWritesToOut(t *testing.T) {
+	input := `let x = 2;
+print(x * 21)
+x + 1
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := PROMPT + PROMPT + "42\nnull\n" + PROMPT + "3\n" + PROMPT
+	if out.String() != expected {
+		t.Errorf("wrong output:\nwant %q\ngot  %q", expected, out.String())
+	}
+}
+
+func TestStartSharesInputWithScripts(t *testing.T) {
+	input := `let name = read_line();
+bob
+name
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	if !strings.Contains(out.String(), "bob\n") {
+		t.Errorf("input() did not read from the REPL input: got %q", out.String())
+	}
+}
+
+func TestNeedsMoreInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 5;", false},
+		{"let add = df(x, y) {", true},
+		{"let add = df(x, y) {\n x + y\n}", false},
+		{"[1, 2,", true},
+		{"[1, 2,\n3]", false},
+		{"add(1,", true},
+		{"5 +", true},
+		{"let x =", true},
+		{`"an open string`, true},
+		{`"a { in a string"`, false},
+		{"// an open comment", true},
+		{"// a ( in a comment // 5", false},
+		{"}", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := needsMoreInput(tt.input); got != tt.expected {
+			t.Errorf("needsMoreInput(%q): got %t, want %t", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestStartMultiLineInput(t *testing.T) {
+	input := `let reduce = df(arr, initial, f) {
+    let iter = df(arr, result) {
+        if (len(arr) == 0) {
+            result
+        } else {
+            iter(rest(arr), f(result, first(arr)));
+        }
+    };
+
+    iter(arr, initial);
+};
+reduce([1, 2, 3], 0, df(acc, el) {
+    acc + el
+})
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	if strings.Contains(out.String(), "Check Syntax") {
+		t.Fatalf("multi-line input produced parser errors: %q", out.String())
+	}
+	if !strings.Contains(out.String(), CONTINUE_PROMPT) {
+		t.Errorf("no continuation prompt shown: %q", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "6\n"+PROMPT) {
+		t.Errorf("wrong result: got %q", out.String())
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	it := evaluate.New()
+	if _, err := it.Eval("let lemon = 1;"); err != nil {
+		t.Fatal(err)
+	}
+	s := &session{it: it, printer: newPrinter(false)}
+	got := strings.Join(s.complete("le"), " ")
+	if got != "lemon len let" {
+		t.Errorf("wrong completions: got %q", got)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "lib.sh")
+	if err := os.WriteFile(script, []byte("let triple = df(x) { x * 3 };\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{":help", []string{":load file", ":reset"}},
+		{"let x = [1, 2];\nlet name = \"bob\";\n:env", []string{"name : STRING", "x : ARRAY"}},
+		{":type 1.5", []string{"FLOAT"}},
+		{":type missing", []string{"identifier not found: missing"}},
+		{":ast 1 + 2 * 3", []string{"(1 + (2 * 3))"}},
+		{":tokens let y", []string{`LET        "let"`, `IDENT      "y"`}},
+		{":load " + script + "\ntriple(4)", []string{"12"}},
+		{":time 2 + 2", []string{"4\n", "time: "}},
+		{"let x = 1;\n:reset\nx", []string{"session reset", "identifier not found: x"}},
+		{":nope", []string{"unknown command :nope"}},
+		{":type", []string{"usage: :type expr"}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input+"\n"), &out)
+		for _, expected := range tt.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("%q: output does not contain %q:\n%s", tt.input, expected, out.String())
+			}
+		}
+	}
+}
+
+func TestSaveCommand(t *testing.T) {
+	saved := filepath.Join(t.TempDir(), "session.sh")
+	input := "let x = 2;\n5 + true\nlet = ;\nlet add = df(a) {\n a + x\n};\n:save " + saved + "\n"
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	content, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatalf("session was not saved: %s", err)
+	}
+	// input that failed to parse or evaluate is left out
+	expected := "let x = 2;\nlet add = df(a) {\n a + x\n};\n"
+	if string(content) != expected {
+		t.Errorf("wrong saved session:\nwant %q\ngot  %q", expected, string(content))
+	}
+
+	// the saved session loads back into a fresh REPL
+	out.Reset()
+	Start(strings.NewReader(":load "+saved+"\nadd(1)\n"), &out)
+	if !strings.Contains(out.String(), "3\n") {
+		t.Errorf("saved session did not load: %q", out.String())
+	}
+}
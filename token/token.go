@@ -1,5 +1,7 @@
 package token
 
+import "sort"
+
 type TokenType string
 
 // string is not the most performant but it is pragmatic
@@ -32,6 +34,7 @@ const (
 	GT     = ">"
 	EQ     = "=="
 	NOT_EQ = "!="
+	IN     = "IN"
 
 	// Delimiters
 	COMMA     = ","
@@ -66,6 +69,7 @@ var keywords = map[string]TokenType{
 	"return": RETURN,
 	"exit":   EXIT,
 	"NULL":   NULL,
+	"in":     IN,
 }
 
 // define language specified keywords versus user defined variables
@@ -75,3 +79,13 @@ func LookupIdent(ident string) TokenType {
 	}
 	return IDENT
 }
+
+// Keywords lists the reserved words of the language, sorted
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}